@@ -0,0 +1,145 @@
+package taomd
+
+import (
+	"strings"
+)
+
+// An Alert is a block quote that starts with a line of only an alert kind,
+// as GitHub has:
+//
+//	> [!NOTE]
+//	> Useful information that users should know.
+//
+// The kinds of GitHub are "NOTE", "TIP", "IMPORTANT", "WARNING" and "CAUTION".
+// More can be added by WithAlertKind.
+type Alert struct {
+	Span
+	Attributes
+
+	// The kind in lowercase, as in "note". It is not named Kind,
+	// which is the kind of the node.
+	Type string
+
+	// The title of the kind, as in "Note".
+	Title string
+
+	blocks []Blocker
+}
+
+// Blocks returns the blocks in the alert, without the line of its kind.
+func (a *Alert) Blocks() []Blocker {
+	return a.blocks
+}
+
+// SetBlocks replaces the blocks in the alert.
+func (a *Alert) SetBlocks(blocks []Blocker) {
+	a.blocks = blocks
+}
+
+// Children returns the blocks in the alert.
+func (a *Alert) Children() []Node {
+	return blocksToNodes(a.blocks)
+}
+
+func (a *Alert) AddLine(p *Parser, s []rune) bool {
+	return false
+}
+
+// The alert kinds of GitHub, and their titles.
+var defaultAlertKinds = map[string]string{
+	"note":      "Note",
+	"tip":       "Tip",
+	"important": "Important",
+	"warning":   "Warning",
+	"caution":   "Caution",
+}
+
+// WithAlertKind adds an alert kind, as in "SECURITY", with the title
+// it is rendered with, or replaces the title of a kind of GitHub.
+// Kinds are case-insensitive. It takes effect only if ExtAlert is enabled.
+func WithAlertKind(kind string, title string) Option {
+	return func(p *Parser) {
+		if p.alertKinds == nil {
+			p.alertKinds = make(map[string]string)
+		}
+		p.alertKinds[strings.ToLower(kind)] = title
+	}
+}
+
+// alertTitle returns the title of kind, which is in lowercase,
+// or false if it is not a known kind.
+func (p *Parser) alertTitle(kind string) (string, bool) {
+	if title, ok := p.alertKinds[kind]; ok {
+		return title, true
+	}
+	title, ok := defaultAlertKinds[kind]
+	return title, ok
+}
+
+// parseAlerts makes alerts of the block quotes whose first lines
+// are alert kinds, before the paragraphs are parsed into inlines.
+func (p *Parser) parseAlerts(doc *Document) {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		c, ok := n.(blockContainer)
+		if !ok || !entering {
+			return WalkContinue
+		}
+		blocks := c.Blocks()
+		for i, block := range blocks {
+			bq, ok := block.(*BlockQuote)
+			if !ok || len(bq.blocks) == 0 {
+				continue
+			}
+			pp, ok := bq.blocks[0].(*Paragraph)
+			if !ok || pp.nextAttributes != nil {
+				continue
+			}
+			kind, rest := pp.parseAlertKind()
+			title, ok := p.alertTitle(kind)
+			if !ok {
+				continue
+			}
+			alert := &Alert{
+				Span:       bq.Span,
+				Attributes: bq.Attributes,
+				Type:       kind,
+				Title:      title,
+				blocks:     bq.blocks[1:],
+			}
+			if rest != nil {
+				alert.blocks = append([]Blocker{rest}, alert.blocks...)
+			}
+			blocks[i] = alert
+		}
+		return WalkContinue
+	})
+}
+
+// parseAlertKind parses the first line of the paragraph as an alert kind,
+// "[!KIND]", and returns the kind in lowercase and the paragraph of
+// the lines after it, which is nil if there are none.
+func (pp *Paragraph) parseAlertKind() (string, *Paragraph) {
+	raw := []rune(strings.Join(pp.texts, ""))
+	n := 0
+	for n < len(raw) && raw[n] != '\n' {
+		n++
+	}
+	line := strings.TrimSpace(string(raw[:n]))
+	if len(line) < 4 || !strings.HasPrefix(line, "[!") || !strings.HasSuffix(line, "]") {
+		return "", nil
+	}
+	kind := strings.ToLower(line[2 : len(line)-1])
+	if n+1 >= len(raw) {
+		return kind, nil
+	}
+
+	n++
+	m := newSourceMap(raw, pp.starts)
+	return kind, &Paragraph{
+		Span:       Span{Start: m[n], End: pp.End},
+		Attributes: pp.Attributes,
+		texts:      []string{string(raw[n:])},
+		starts:     m.skip(raw, pp.starts, n),
+		closed:     true,
+	}
+}
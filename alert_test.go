@@ -0,0 +1,56 @@
+package taomd
+
+import (
+	"testing"
+)
+
+var alertExamples = []*Example{
+	{
+		Markdown: "> [!NOTE]\n> Useful information.\n",
+		HTML:     "<div class=\"markdown-alert markdown-alert-note\">\n<p class=\"markdown-alert-title\">Note</p>\n<p>Useful information.</p>\n</div>\n",
+		Section:  "Alerts",
+	},
+	{
+		Markdown: "> [!warning]\n> Careful.\n>\n> - a\n",
+		HTML:     "<div class=\"markdown-alert markdown-alert-warning\">\n<p class=\"markdown-alert-title\">Warning</p>\n<p>Careful.</p>\n<ul>\n<li>a</li>\n</ul>\n</div>\n",
+		Section:  "Alerts",
+	},
+	{
+		Markdown: "> [!NOTE]\n> > nested\n",
+		HTML:     "<div class=\"markdown-alert markdown-alert-note\">\n<p class=\"markdown-alert-title\">Note</p>\n<blockquote>\n<p>nested</p>\n</blockquote>\n</div>\n",
+		Section:  "Alerts",
+	},
+	{
+		Markdown: "> [!TIP] text\n",
+		HTML:     "<blockquote>\n<p>[!TIP] text</p>\n</blockquote>\n",
+		Section:  "Alerts",
+	},
+	{
+		Markdown: "> [!UNKNOWN]\n> text\n",
+		HTML:     "<blockquote>\n<p>[!UNKNOWN]\ntext</p>\n</blockquote>\n",
+		Section:  "Alerts",
+	},
+}
+
+func TestAlerts(t *testing.T) {
+	testExamples(t, alertExamples, WithExtensions(ExtAlert))
+}
+
+func TestAlertsRoundTrip(t *testing.T) {
+	testRoundTrip(t, alertExamples, WithExtensions(ExtAlert))
+}
+
+func TestAlertKind(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "> [!SECURITY]\n> text\n",
+			HTML:     "<div class=\"markdown-alert markdown-alert-security\">\n<p class=\"markdown-alert-title\">Security</p>\n<p>text</p>\n</div>\n",
+			Section:  "Alert kinds",
+		},
+		{
+			Markdown: "> [!NOTE]\n> text\n",
+			HTML:     "<div class=\"markdown-alert markdown-alert-note\">\n<p class=\"markdown-alert-title\">注意</p>\n<p>text</p>\n</div>\n",
+			Section:  "Alert kinds",
+		},
+	}, WithExtensions(ExtAlert), WithAlertKind("security", "Security"), WithAlertKind("NOTE", "注意"))
+}
@@ -0,0 +1,357 @@
+package taomd
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// An Attribute is an HTML attribute given by an attribute list.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Attributes are the HTML attributes given to a node by an attribute list,
+// as in "{#id .class key=value}". An id and classes, if any, come first,
+// with all the classes in a single "class" attribute.
+//
+// Attribute lists can be written:
+//
+//   - at the end of a heading, as in "## Heading {.class}",
+//   - at the end of the info string of a fenced code block, as in "```go {.class}",
+//   - right after a link or an image, as in "![alt](src){width=100}",
+//   - on a line of its own right before a block.
+type Attributes []Attribute
+
+// attributer is implemented by nodes that can have attributes, by embedding Attributes.
+type attributer interface {
+	attributes() *Attributes
+}
+
+func (a *Attributes) attributes() *Attributes {
+	return a
+}
+
+// merge merges other into a. Classes are added, other attributes are replaced.
+func (a *Attributes) merge(other Attributes) {
+	for _, attr := range other {
+		a.set(attr.Key, attr.Value)
+	}
+}
+
+// set sets the attribute key to value, keeping the id and the classes first.
+func (a *Attributes) set(key string, value string) {
+	for i, attr := range *a {
+		if attr.Key != key {
+			continue
+		}
+		if key == "class" {
+			value = attr.Value + " " + value
+		}
+		(*a)[i].Value = value
+		return
+	}
+
+	attr := Attribute{Key: key, Value: value}
+	n := 0
+	switch key {
+	case "id":
+	case "class":
+		if len(*a) > 0 && (*a)[0].Key == "id" {
+			n = 1
+		}
+	default:
+		n = len(*a)
+	}
+	*a = append(*a, Attribute{})
+	copy((*a)[n+1:], (*a)[n:])
+	(*a)[n] = attr
+}
+
+// take removes the attribute key from a, and returns its value.
+func (a *Attributes) take(key string) string {
+	for i, attr := range *a {
+		if attr.Key == key {
+			*a = append((*a)[:i], (*a)[i+1:]...)
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// parseAttributes parses an attribute list at the start of s, and returns
+// its length and the attributes, or 0 if there is none.
+//
+// An attribute list is enclosed in braces, with an optional colon after
+// the opening brace, as kramdown has, and consists of one or more of:
+//
+//   - "#id" for the id,
+//   - ".class" for a class,
+//   - "key=value", where value can be double or single quoted,
+//
+// separated by spaces.
+func parseAttributes(s []rune) (int, Attributes) {
+	if len(s) < 2 || s[0] != '{' {
+		return 0, nil
+	}
+	i := 1
+	if s[i] == ':' {
+		i++
+	}
+
+	var attrs Attributes
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+			i++
+		}
+		if i >= len(s) {
+			return 0, nil
+		}
+
+		switch s[i] {
+		case '}':
+			if len(attrs) == 0 {
+				return 0, nil
+			}
+			return i + 1, attrs
+		case '#', '.':
+			j := i + 1
+			for j < len(s) && isAttributeNameChar(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				return 0, nil
+			}
+			key := "id"
+			if s[i] == '.' {
+				key = "class"
+			}
+			attrs.set(key, string(s[i+1:j]))
+			i = j
+		default:
+			j := i
+			for j < len(s) && isAttributeNameChar(s[j]) {
+				j++
+			}
+			if j == i || j >= len(s) || s[j] != '=' {
+				return 0, nil
+			}
+			key := string(s[i:j])
+			n, value := parseAttributeValue(s[j+1:])
+			if n == 0 {
+				return 0, nil
+			}
+			attrs.set(key, value)
+			i = j + 1 + n
+		}
+
+		// Attributes are separated by spaces.
+		if i < len(s) && s[i] != ' ' && s[i] != '\t' && s[i] != '}' {
+			return 0, nil
+		}
+	}
+}
+
+// parseAttributeValue parses a value at the start of s, and returns its length
+// and the value without quotes, or 0 if there is none.
+func parseAttributeValue(s []rune) (int, string) {
+	if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+		for i := 1; i < len(s); i++ {
+			if s[i] == s[0] {
+				return i + 1, string(s[1:i])
+			}
+			if s[i] == '\n' {
+				break
+			}
+		}
+		return 0, ""
+	}
+	i := 0
+	for i < len(s) && !strings.ContainsRune(" \t\n\"'=<>`{}", s[i]) {
+		i++
+	}
+	return i, string(s[:i])
+}
+
+func isAttributeNameChar(r rune) bool {
+	return r == '-' || r == '_' || r == ':' || r < utf8.RuneSelf && isAlNum(r)
+}
+
+// isAttributeName tells whether name is a valid attribute name of an attribute list.
+func isAttributeName(name string) bool {
+	for _, r := range name {
+		if !isAttributeNameChar(r) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// isAttributeLine tells whether the line at the start of s is only an attribute list.
+func isAttributeLine(s string) bool {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	r := []rune(s)
+	n, _ := parseAttributes(r)
+	return n > 0 && isBlankLine(r[n:])
+}
+
+// trailingAttributes finds the attribute list at the end of s, after which
+// there may be only spaces, and returns s before it and the attributes.
+// s is returned as it is if there is none.
+func trailingAttributes(s string) (string, Attributes) {
+	text := strings.TrimRight(s, " \t\n")
+	if !strings.HasSuffix(text, "}") {
+		return s, nil
+	}
+	i := strings.LastIndex(text, "{")
+	if i < 0 || i > 0 && text[i-1] == '\\' {
+		return s, nil
+	}
+	r := []rune(text[i:])
+	n, attrs := parseAttributes(r)
+	if n != len(r) {
+		return s, nil
+	}
+	return strings.TrimRight(text[:i], " \t") + s[len(text):], attrs
+}
+
+// tryParseAttributeLine parses s as a line of only an attribute list,
+// which gives attributes to the block after it.
+// It cannot interrupt a paragraph.
+func (p *Parser) tryParseAttributeLine(s []rune) *Paragraph {
+	if _, ok := p.tip.(*Paragraph); ok {
+		return nil
+	}
+	n, attrs := parseAttributes(s)
+	if n == 0 || !isBlankLine(s[n:]) {
+		return nil
+	}
+	return &Paragraph{
+		texts:          []string{string(s)},
+		starts:         []Position{p.posOf(s)},
+		closed:         true,
+		nextAttributes: attrs,
+	}
+}
+
+// applyBlockAttributes gives the attributes on attribute lines to the blocks
+// right after them. An attribute line is left as a paragraph if there is
+// no such block, or the block cannot have attributes.
+func (doc *Document) applyBlockAttributes() {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		c, ok := n.(blockContainer)
+		if !ok || !entering {
+			return WalkContinue
+		}
+		blocks := c.Blocks()
+		var result []Blocker
+		for i := 0; i < len(blocks); i++ {
+			pp, ok := blocks[i].(*Paragraph)
+			if !ok || pp.nextAttributes == nil || i+1 >= len(blocks) {
+				result = append(result, blocks[i])
+				continue
+			}
+			next, ok := blocks[i+1].(attributer)
+			if np, isParagraph := next.(*Paragraph); !ok || isParagraph && np.nextAttributes != nil {
+				result = append(result, blocks[i])
+				continue
+			}
+			if h, ok := next.(*Heading); ok {
+				if id := pp.nextAttributes.take("id"); id != "" {
+					h.ID = id
+					h.explicitID = true
+				}
+			}
+			next.attributes().merge(pp.nextAttributes)
+		}
+		c.SetBlocks(result)
+		return WalkContinue
+	})
+}
+
+// parseCodeBlockAttributes takes attribute lists off the ends of
+// the info strings of fenced code blocks.
+func (doc *Document) parseCodeBlockAttributes() {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		cb, ok := n.(*CodeBlock)
+		if !ok || !entering || !cb.isFenced() {
+			return WalkContinue
+		}
+		info, attrs := trailingAttributes(cb.Info)
+		if attrs == nil {
+			return WalkContinue
+		}
+		cb.Attributes.merge(attrs)
+		cb.Info = strings.TrimSpace(info)
+		cb.Lang, cb.Args = cb.Info, ""
+		if p := strings.IndexAny(cb.Info, " \t"); p != -1 {
+			cb.Lang, cb.Args = cb.Info[:p], cb.Info[p:]
+		}
+		return WalkContinue
+	})
+}
+
+// parseInlineAttributes gives the attribute lists right after links and images to them.
+func parseInlineAttributes(inlines []Inline) []Inline {
+	var result []Inline
+	for i := 0; i < len(inlines); i++ {
+		switch typed := inlines[i].(type) {
+		case *Emphasis:
+			typed.Inlines = parseInlineAttributes(typed.Inlines)
+		case *Strikethrough:
+			typed.Inlines = parseInlineAttributes(typed.Inlines)
+		case *Link:
+			typed.Inlines = parseInlineAttributes(typed.Inlines)
+			i = takeInlineAttributes(&typed.Attributes, inlines, i+1, &result) - 1
+			continue
+		case *Image:
+			i = takeInlineAttributes(&typed.Attributes, inlines, i+1, &result) - 1
+			continue
+		}
+		result = append(result, inlines[i])
+	}
+	return result
+}
+
+// takeInlineAttributes takes an attribute list from the texts starting
+// at inlines[i] into attrs, and appends inlines[i-1] and what is left of
+// the texts to result. It returns the index of the inline after the texts.
+//
+// Adjacent texts are taken as a whole, for unmatched delimiters are left
+// as texts, as in "{data_x=1}".
+func takeInlineAttributes(attrs *Attributes, inlines []Inline, i int, result *[]Inline) int {
+	*result = append(*result, inlines[i-1])
+
+	j := i
+	var text string
+	for ; j < len(inlines); j++ {
+		t, ok := inlines[j].(*Text)
+		if !ok {
+			break
+		}
+		text += t.Text
+	}
+
+	n, list := parseAttributes([]rune(text))
+	if n == 0 {
+		return i
+	}
+	attrs.merge(list)
+
+	first, last := inlines[i].(*Text), inlines[j-1].(*Text)
+	rest := []rune(text)[n:]
+	if len(rest) > 0 {
+		start := first.Start
+		// The position is known only if the text is as it is in the source.
+		if first.Start.Line == last.End.Line && last.End.Offset-first.Start.Offset == len(text) {
+			start = first.Start.advance([]rune(text)[:n])
+		}
+		*result = append(*result, &Text{
+			Span: Span{Start: start, End: last.End},
+			Text: string(rest),
+		})
+	}
+	return j
+}
@@ -0,0 +1,111 @@
+package taomd
+
+import (
+	"strings"
+	"testing"
+)
+
+var attributeExamples = []*Example{
+	{
+		Markdown: "# Heading {#id .a .b}\n",
+		HTML:     "<h1 id=\"id\" class=\"a b\">Heading</h1>\n",
+		Section:  "Headings",
+	},
+	{
+		Markdown: "## Heading {id=x}\n",
+		HTML:     "<h2 id=\"x\">Heading</h2>\n",
+		Section:  "Headings",
+	},
+	{
+		Markdown: "```go {.c}\nx\n```\n",
+		HTML:     "<pre class=\"c\"><code class=\"language-go\">x\n</code></pre>\n",
+		Section:  "Code blocks",
+	},
+	{
+		Markdown: "[a](/u \"t\"){.c target=_blank}\n",
+		HTML:     "<p><a href=\"/u\" title=\"t\" class=\"c\" target=\"_blank\">a</a></p>\n",
+		Section:  "Links",
+	},
+	{
+		Markdown: "![a](/i){width=100}\n",
+		HTML:     "<p><img src=\"/i\" alt=\"a\" width=\"100\" /></p>\n",
+		Section:  "Images",
+	},
+	{
+		Markdown: "{.lead}\nIntro\n",
+		HTML:     "<p class=\"lead\">Intro</p>\n",
+		Section:  "Attribute lines",
+	},
+	{
+		Markdown: "{#i .c}\n---\n",
+		HTML:     "<hr id=\"i\" class=\"c\" />\n",
+		Section:  "Attribute lines",
+	},
+	{
+		Markdown: "{data-x=1}\n> q\n",
+		HTML:     "<blockquote data-x=\"1\">\n<p>q</p>\n</blockquote>\n",
+		Section:  "Attribute lines",
+	},
+	{
+		Markdown: "Text\n{.c}\n",
+		HTML:     "<p>Text\n{.c}</p>\n",
+		Section:  "Attribute lines",
+	},
+	{
+		Markdown: "a@b.co{#id .c} and www.x.com {.d}\n",
+		HTML:     "<p><a href=\"mailto:a@b.co\" id=\"id\" class=\"c\">a@b.co</a> and <a href=\"http://www.x.com\">www.x.com</a> {.d}</p>\n",
+		Section:  "Autolinks",
+	},
+}
+
+func TestAttributes(t *testing.T) {
+	testExamples(t, attributeExamples, WithExtensions(ExtAttributes|ExtAutolink))
+}
+
+func TestAttributesRoundTrip(t *testing.T) {
+	testRoundTrip(t, attributeExamples, WithExtensions(ExtAttributes|ExtAutolink))
+}
+
+func TestAttributesUnsafe(t *testing.T) {
+	examples := []*Example{
+		{
+			Markdown: "![a](/i){onerror=\"alert(1)\"}\n",
+			HTML:     "<p><img src=\"/i\" alt=\"a\" /></p>\n",
+			Section:  "Event handlers",
+		},
+		{
+			Markdown: "{onclick=\"alert(1)\" data-x=1}\n> q\n",
+			HTML:     "<blockquote data-x=\"1\">\n<p>q</p>\n</blockquote>\n",
+			Section:  "Event handlers",
+		},
+		{
+			Markdown: "# Heading {ONMOUSEOVER=x}\n",
+			HTML:     "<h1>Heading</h1>\n",
+			Section:  "Event handlers",
+		},
+		{
+			Markdown: "[a](/u){href=\"javascript:alert(1)\" title=x}\n",
+			HTML:     "<p><a href=\"/u\">a</a></p>\n",
+			Section:  "Written attributes",
+		},
+		{
+			Markdown: "![a](/i \"t\"){src=x alt=y title=z}\n",
+			HTML:     "<p><img src=\"/i\" alt=\"a\" title=\"t\" /></p>\n",
+			Section:  "Written attributes",
+		},
+		{
+			Markdown: "{start=5}\n2. a\n",
+			HTML:     "<ol start=\"2\">\n<li>a</li>\n</ol>\n",
+			Section:  "Written attributes",
+		},
+	}
+	testExamples(t, examples, WithExtensions(ExtAttributes|ExtTagFilter))
+
+	// Event handlers are written only if asked for.
+	r := NewRenderer(WithUnsafeAttributes())
+	doc := Parse(strings.NewReader(examples[0].Markdown), WithExtensions(ExtAttributes))
+	want := "<p><img src=\"/i\" alt=\"a\" onerror=\"alert(1)\" /></p>\n"
+	if html := r.Render(doc); html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+}
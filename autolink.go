@@ -0,0 +1,293 @@
+package taomd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// parseExtendedAutolinks finds extended autolinks in the texts of inlines,
+// except those in links and images.
+// https://github.github.com/gfm/#autolinks-extension-
+func parseExtendedAutolinks(inlines []Inline) []Inline {
+	var result []Inline
+	for i := 0; i < len(inlines); i++ {
+		switch typed := inlines[i].(type) {
+		case *Emphasis:
+			typed.Inlines = parseExtendedAutolinks(typed.Inlines)
+		case *Strikethrough:
+			typed.Inlines = parseExtendedAutolinks(typed.Inlines)
+		case *Text:
+			// Adjacent texts are searched as a whole,
+			// for unmatched delimiters are left as texts, as in "a_b@c.d".
+			j := i
+			for j < len(inlines) {
+				if _, ok := inlines[j].(*Text); !ok {
+					break
+				}
+				j++
+			}
+			result = append(result, splitExtendedAutolinks(inlines[i:j])...)
+			i = j - 1
+			continue
+		}
+		result = append(result, inlines[i])
+	}
+	return result
+}
+
+// splitExtendedAutolinks splits texts into texts and links.
+// texts are returned as is if there are no links in them.
+func splitExtendedAutolinks(texts []Inline) []Inline {
+	var s []rune
+	var positions []Position
+	for _, inline := range texts {
+		t := inline.(*Text)
+		r := []rune(t.Text)
+		s = append(s, r...)
+		// The position of each character is known only if the text
+		// is as it is in the source, which it is not if it has escapes.
+		exact := t.End.Offset-t.Start.Offset == len(t.Text)
+		pos := t.Start
+		for i := range r {
+			positions = append(positions, pos)
+			if exact {
+				pos = pos.advance(r[i : i+1])
+			}
+		}
+	}
+	if len(texts) > 0 {
+		positions = append(positions, texts[len(texts)-1].Pos().End)
+	}
+
+	var result []Inline
+	last := 0
+	span := func(start, end int) Span {
+		return Span{Start: positions[start], End: positions[end]}
+	}
+	appendText := func(end int) {
+		if end > last {
+			result = append(result, &Text{
+				Span: span(last, end),
+				Text: string(s[last:end]),
+			})
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		start, end, dest := i, 0, ""
+		switch {
+		case s[i] == '@':
+			for start > last && isEmailLocalChar(s[start-1]) {
+				start--
+			}
+			if start == i {
+				continue
+			}
+			n := scanEmailDomain(s[i+1:])
+			if n == 0 {
+				continue
+			}
+			end = i + 1 + n
+			dest = "mailto:" + string(s[start:end])
+		default:
+			var n int
+			n, dest = scanExtendedURL(s, i)
+			if n == 0 {
+				continue
+			}
+			end = i + n
+		}
+
+		appendText(start)
+		sp := span(start, end)
+		result = append(result, &Link{
+			Span: sp,
+			Inlines: []Inline{
+				&Text{
+					Span: sp,
+					Text: string(s[start:end]),
+				},
+			},
+			Link:  dest,
+			Style: ExtendedAutolink,
+		})
+		last = end
+		i = end - 1
+	}
+
+	if last == 0 {
+		return texts
+	}
+	appendText(len(s))
+	return result
+}
+
+// scanExtendedURL scans an extended www autolink or url autolink at s[i:],
+// and returns its length and destination, or 0 if there is none.
+func scanExtendedURL(s []rune, i int) (int, string) {
+	c := s[i:]
+	hasPrefix := func(prefix string) bool {
+		return len(c) >= len(prefix) && string(c[:len(prefix)]) == prefix
+	}
+
+	var domain int
+	var scheme string
+
+	switch {
+	// An extended www autolink will be recognized when the text www. is found
+	// followed by a valid domain. It must be at the beginning of a line,
+	// after whitespace, or any of the delimiting characters *, _, ~, and (.
+	case hasPrefix("www."):
+		if i > 0 && !unicode.IsSpace(s[i-1]) && !strings.ContainsRune("*_~(", s[i-1]) {
+			return 0, ""
+		}
+		if domain = scanDomain(c, false); domain == 0 {
+			return 0, ""
+		}
+		scheme = "http://"
+
+	// An extended url autolink will be recognised when one of the schemes
+	// http://, https://, or ftp://, followed by a valid domain, is found.
+	case hasPrefix("http://"), hasPrefix("https://"), hasPrefix("ftp://"):
+		if i > 0 && unicode.IsLetter(s[i-1]) {
+			return 0, ""
+		}
+		n := strings.Index(string(c), "://") + 3
+		if domain = scanDomain(c[n:], true); domain == 0 {
+			return 0, ""
+		}
+		domain += n
+
+	default:
+		return 0, ""
+	}
+
+	// After a valid domain, zero or more non-space non-< characters may follow.
+	end := domain
+	for end < len(c) && !unicode.IsSpace(c[end]) && c[end] != '<' {
+		end++
+	}
+	end = trimAutolinkTrail(c[:end])
+
+	return end, scheme + string(c[:end])
+}
+
+// scanDomain scans a valid domain at the start of s and returns its length,
+// or 0 if there is none.
+//
+// A valid domain consists of segments of alphanumeric characters,
+// underscores (_) and hyphens (-) separated by periods (.).
+// There must be at least one period, unless short is true,
+// and no underscores may be present in the last two segments of the domain.
+func scanDomain(s []rune, short bool) int {
+	periods := 0
+	underscores := [2]int{} // in the last two segments
+	i := 0
+	for ; i < len(s); i++ {
+		r := s[i]
+		switch {
+		case r == '.':
+			// A period is not a part of the domain unless a segment follows.
+			if i+1 >= len(s) || !isDomainChar(s[i+1]) {
+				break
+			}
+			periods++
+			underscores[1] = underscores[0]
+			underscores[0] = 0
+			continue
+		case r == '_':
+			underscores[0]++
+			continue
+		case isDomainChar(r):
+			continue
+		}
+		break
+	}
+	if i == 0 || underscores[0] > 0 || underscores[1] > 0 {
+		return 0
+	}
+	if periods == 0 && !short {
+		return 0
+	}
+	return i
+}
+
+func isDomainChar(r rune) bool {
+	return r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// trimAutolinkTrail returns the length of link without trailing
+// punctuation that is not considered part of an extended autolink.
+func trimAutolinkTrail(link []rune) int {
+	n := len(link)
+	for n > 0 {
+		switch link[n-1] {
+		// Trailing punctuation (specifically, ?, !, ., ,, :, *, _, and ~)
+		// will not be considered part of the autolink.
+		case '?', '!', '.', ',', ':', '*', '_', '~':
+			n--
+			continue
+
+		// When an autolink ends in ), we scan the entire autolink for the total
+		// number of parentheses. If there is a greater number of closing parentheses
+		// than opening ones, we don't consider the unmatched trailing parentheses
+		// part of the autolink.
+		case ')':
+			opening, closing := 0, 0
+			for _, r := range link[:n] {
+				switch r {
+				case '(':
+					opening++
+				case ')':
+					closing++
+				}
+			}
+			if closing > opening {
+				n--
+				continue
+			}
+
+		// If an autolink ends in a semicolon (;), we check to see if it appears
+		// to resemble an entity reference; if the preceding text is & followed
+		// by one or more alphanumeric characters. If so, it is excluded from the autolink.
+		case ';':
+			i := n - 1
+			for i > 0 && isAlNum(link[i-1]) {
+				i--
+			}
+			if i > 0 && i < n-1 && link[i-1] == '&' {
+				n = i - 1
+				continue
+			}
+		}
+		break
+	}
+	return n
+}
+
+func isEmailLocalChar(r rune) bool {
+	return r == '.' || r == '+' || r == '-' || r == '_' || isAlNum(r)
+}
+
+// scanEmailDomain scans the domain of an email autolink at the start of s,
+// and returns its length, or 0 if it is not valid.
+//
+// The domain consists of one or more characters which are alphanumeric,
+// or -, or _, or ., with at least one period. The last character must not
+// be one of - or _, and if it is ., it is excluded from the autolink.
+func scanEmailDomain(s []rune) int {
+	n := 0
+	for n < len(s) && (s[n] == '-' || s[n] == '_' || s[n] == '.' || isAlNum(s[n])) {
+		n++
+	}
+	if n > 0 && s[n-1] == '.' {
+		n--
+	}
+	if n == 0 || s[n-1] == '-' || s[n-1] == '_' {
+		return 0
+	}
+	if !strings.ContainsRune(string(s[:n]), '.') {
+		return 0
+	}
+	return n
+}
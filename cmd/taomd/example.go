@@ -146,7 +146,7 @@ func testFunc(examples []*Example, predicate func(example *Example) bool, result
 				panic(e)
 			}
 		}()
-		doc := taomd.Parse(strings.NewReader(md))
+		doc := taomd.Parse(strings.NewReader(md), taomd.WithExtensions(taomd.CommonMark))
 		return taomd.Render(doc)
 	}
 
@@ -185,3 +185,27 @@ func dumpResult(example *Example, html string, pass bool) {
 		taomd.DumpFail(os.Stderr, example.Markdown, example.HTML, html)
 	}
 }
+
+// testRoundTrip tests that rendering examples as markdown and parsing them
+// back gives documents that render to the same html as the examples do.
+func testRoundTrip(examples []*Example) {
+	var total, passed, failed int
+
+	for _, example := range examples {
+		total++
+		doc := taomd.Parse(strings.NewReader(example.Markdown))
+		want := taomd.Render(doc)
+		md := taomd.RenderMarkdown(doc)
+		given := taomd.Render(taomd.Parse(strings.NewReader(md)))
+		if given == want {
+			passed++
+			fmt.Fprintf(os.Stdout, "pass: %d\n", example.Example)
+			continue
+		}
+		failed++
+		fmt.Fprintf(os.Stderr, "fail: %d\n", example.Example)
+		taomd.DumpFail(os.Stderr, md, want, given)
+	}
+
+	fmt.Fprintf(os.Stderr, "\ntotal: %d, passed: %d, failed: %d\n", total, passed, failed)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/movsb/taomd"
+)
+
+// Documents are formatted as GFM, and front matter is kept as it is,
+// instead of being formatted as markdown.
+var formatOptions = []taomd.Option{
+	taomd.WithExtensions(taomd.GFM | taomd.ExtFrontMatter),
+}
+
+// format formats files as normalized markdown. If write is true,
+// files are overwritten, otherwise the results are written to stdout.
+// Stdin is formatted if there are no files.
+func format(files []string, write bool) error {
+	if len(files) == 0 {
+		doc, err := parse(os.Stdin)
+		if err != nil {
+			return err
+		}
+		return taomd.RenderMarkdownTo(os.Stdout, doc)
+	}
+
+	for _, file := range files {
+		if err := formatFile(file, write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parse parses in for formatting. Front matter which cannot be decoded
+// is not an error, for it is written back as it is.
+func parse(in io.Reader) (*taomd.Document, error) {
+	doc, err := taomd.ParseReader(in, formatOptions...)
+	if _, ok := err.(*taomd.FrontMatterError); ok {
+		err = nil
+	}
+	return doc, err
+}
+
+func formatFile(file string, write bool) error {
+	fp, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	doc, err := parse(fp)
+	fp.Close()
+	if err != nil {
+		return err
+	}
+
+	if !write {
+		return taomd.RenderMarkdownTo(os.Stdout, doc)
+	}
+
+	var buf bytes.Buffer
+	if err := taomd.RenderMarkdownTo(&buf, doc); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
@@ -11,20 +11,42 @@ import (
 
 func main() {
 	if len(os.Args) == 1 {
-		doc := taomd.Parse(os.Stdin)
-		html := taomd.Render(doc)
-		fmt.Print(html)
+		doc, err := taomd.ParseReader(os.Stdin)
+		if err == nil {
+			err = taomd.RenderTo(os.Stdout, doc)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 		os.Exit(0)
 	}
 
-	examples := loadExamples("spec.json")
-
 	i := 1
 
 	switch os.Args[i] {
 	default:
 		panic("unknown command")
+	case "fmt":
+		write := false
+		for i++; i < len(os.Args) && strings.HasPrefix(os.Args[i], "-"); i++ {
+			switch os.Args[i] {
+			default:
+				panic("unknown arguments: " + os.Args[i])
+			case "-w":
+				write = true
+			}
+		}
+		if err := format(os.Args[i:], write); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "roundtrip":
+		examples := loadExamples("spec.json")
+		testRoundTrip(examples)
 	case "test":
+		examples := loadExamples("spec.json")
+
 		var (
 			loop    = true
 			all     = false
@@ -0,0 +1,354 @@
+package taomd
+
+import (
+	"strings"
+)
+
+// A DefinitionList is a list of terms and their descriptions, as in:
+//
+//	Apple
+//	:   A fruit.
+//	:   A company.
+//
+//	Orange
+//	:   Another fruit.
+//
+// Each line of a paragraph right before a description is a term,
+// and a term can have several descriptions.
+type DefinitionList struct {
+	Span
+	Attributes
+
+	// A definition list is loose if any of its descriptions is separated from
+	// the term or the description before it by blank lines, or if any of its
+	// descriptions directly contains two block-level elements with a blank line
+	// between them. Otherwise it is tight.
+	//
+	// Blank lines before terms do not count, for a term after a description
+	// has to be separated from it by a blank line.
+	Tight bool
+
+	// The terms, the descriptions, and the blank lines between them.
+	Items []Blocker
+}
+
+// A DefinitionTerm is a term in a definition list.
+type DefinitionTerm struct {
+	Span
+
+	Inlines []Inline
+	text    string
+	starts  []Position
+}
+
+// A DefinitionDescription is a description of the terms before it.
+// It starts with a colon (:), and continues on lines indented by 4 spaces,
+// and on lazy continuation lines of a paragraph.
+type DefinitionDescription struct {
+	Span
+
+	blocks []Blocker
+	closed bool
+}
+
+// Children returns the items of the list.
+func (dl *DefinitionList) Children() []Node {
+	return blocksToNodes(dl.Items)
+}
+
+// Children returns the inlines of the term.
+func (dt *DefinitionTerm) Children() []Node {
+	return inlinesToNodes(dt.Inlines)
+}
+
+// TextContent returns the text of the term without markups.
+func (dt *DefinitionTerm) TextContent() (s string) {
+	for _, inline := range dt.Inlines {
+		s += textContent(inline)
+	}
+	return s
+}
+
+// Blocks returns the blocks in the description.
+func (dd *DefinitionDescription) Blocks() []Blocker {
+	return dd.blocks
+}
+
+// SetBlocks replaces the blocks in the description.
+func (dd *DefinitionDescription) SetBlocks(blocks []Blocker) {
+	dd.blocks = blocks
+}
+
+// Children returns the blocks in the description.
+func (dd *DefinitionDescription) Children() []Node {
+	return blocksToNodes(dd.blocks)
+}
+
+func (dl *DefinitionList) AddLine(p *Parser, s []rune) bool {
+	dd := dl.lastDescription()
+	if dd == nil || !dd.AddLine(p, s) {
+		return false
+	}
+	p.extendBlock(dd, s)
+	if isBlankLine(s) {
+		dl.Items = append(dl.Items, p.newBlankLine(s))
+	}
+	return true
+}
+
+// lastDescription returns the last item of the list if it is a description.
+func (dl *DefinitionList) lastDescription() *DefinitionDescription {
+	for i := len(dl.Items) - 1; i >= 0; i-- {
+		if _, ok := dl.Items[i].(*BlankLine); !ok {
+			dd, _ := dl.Items[i].(*DefinitionDescription)
+			return dd
+		}
+	}
+	return nil
+}
+
+func (dl *DefinitionList) addLaziness(p *Parser, s []rune) bool {
+	if dd := dl.lastDescription(); dd != nil {
+		return dd.addLaziness(p, s)
+	}
+	return false
+}
+
+func (dt *DefinitionTerm) AddLine(p *Parser, s []rune) bool {
+	return false
+}
+
+func (dt *DefinitionTerm) parseInlines(p *Parser) {
+	dt.Inlines = p.parseInlines(dt.text, dt.starts)
+}
+
+// The content of a description continues on lines indented by 4 spaces.
+const descriptionIndent = 4
+
+func (dd *DefinitionDescription) AddLine(p *Parser, s []rune) bool {
+	if dd.closed {
+		return false
+	}
+
+	if len(s) == 1 && s[0] == '\n' {
+		if len(dd.blocks) > 0 && dd.blocks[len(dd.blocks)-1].AddLine(p, s) {
+			return true
+		}
+		dd.blocks = append(dd.blocks, p.newBlankLine(s))
+		return true
+	}
+
+	if _, n := peekSpaces(s, descriptionIndent); n == descriptionIndent {
+		if p.addLine(&dd.blocks, s[n:]) {
+			p.tryMergeSetextHeading(&dd.blocks)
+			p.tryMergeTableHeader(&dd.blocks)
+			return true
+		}
+	} else if !isDescriptionStart(s) && dd.addLaziness(p, s) {
+		// The start of the next description is not a lazy continuation line.
+		return true
+	}
+
+	dd.closed = true
+	return false
+}
+
+func (dd *DefinitionDescription) addLaziness(p *Parser, s []rune) bool {
+	if len(dd.blocks) == 0 {
+		return false
+	}
+	switch typed := dd.blocks[len(dd.blocks)-1].(type) {
+	case *List:
+		return typed.addLaziness(p, s)
+	case *BlockQuote:
+		return typed.addLaziness(p, s)
+	case *Paragraph:
+		return typed.addLaziness(p, s)
+	case *DefinitionList:
+		return typed.addLaziness(p, s)
+	}
+	return false
+}
+
+// isDescriptionStart tells whether s, after up to 3 spaces of indentation,
+// starts with a colon (:) followed by a space, a tab, or the end of the line.
+func isDescriptionStart(s []rune) bool {
+	_, s = skipPrefixSpaces(s, 3)
+	return len(s) > 0 && s[0] == ':' &&
+		(len(s) == 1 || s[1] == ' ' || s[1] == '\t' || s[1] == '\n')
+}
+
+// tryParseDefinitionDescription parses the start of a description,
+// which is a colon (:) followed by the start of the content on the same line.
+//
+// A description follows, possibly after blank lines, either a paragraph,
+// whose lines are made terms, or a definition list, to which it is added.
+// The paragraph is added to the definition list right before it, if any.
+func (p *Parser) tryParseDefinitionDescription(pBlocks *[]Blocker, s []rune) bool {
+	if !isDescriptionStart(s) {
+		return false
+	}
+
+	blocks := *pBlocks
+	n := len(blocks)
+	for n > 0 {
+		if _, ok := blocks[n-1].(*BlankLine); !ok {
+			break
+		}
+		n--
+	}
+	if n == 0 {
+		return false
+	}
+	blanks := blocks[n:]
+
+	// The list replaces blocks[i:].
+	i := n - 1
+	var dl *DefinitionList
+	switch typed := blocks[i].(type) {
+	case *DefinitionList:
+		dl = typed
+	case *Paragraph:
+		if typed.nextAttributes != nil || !typed.hasTerms() {
+			return false
+		}
+		if i > 0 {
+			if prev, ok := blocks[i-1].(*DefinitionList); ok {
+				dl = prev
+				i--
+			}
+		}
+		if dl == nil {
+			dl = &DefinitionList{
+				Span: Span{Start: typed.Start},
+			}
+		}
+		// The paragraph is made terms by Document.parseTerms.
+		dl.Items = append(dl.Items, typed)
+	default:
+		return false
+	}
+	dl.Items = append(dl.Items, blanks...)
+
+	dd := &DefinitionDescription{}
+	p.startBlock(dd, s)
+	p.tip = dd
+	_, s = skipPrefixSpaces(s, 3)
+	_, rest := skipPrefixSpaces(s[1:], -1)
+	if !isBlankLine(rest) {
+		p.addLine(&dd.blocks, rest)
+	}
+	dl.Items = append(dl.Items, dd)
+	dl.End = dd.End
+
+	*pBlocks = append(blocks[:i], dl)
+	return true
+}
+
+// hasTerms tells whether any text is left in the paragraph for terms
+// after link reference definitions, which are not taken off it yet.
+func (pp *Paragraph) hasTerms() bool {
+	raw := []rune(strings.Join(pp.texts, ""))
+	for {
+		remain, link := tryParseLinkReferenceDefinition(raw)
+		if link == nil {
+			break
+		}
+		raw = remain
+	}
+	return strings.TrimSpace(string(raw)) != ""
+}
+
+// parseTerms makes terms of the paragraphs in definition lists.
+//
+// The paragraphs are kept as they are until the whole document is parsed,
+// so that link reference definitions are taken off them in document order.
+func (doc *Document) parseTerms() {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		dl, ok := n.(*DefinitionList)
+		if !ok || !entering {
+			return WalkContinue
+		}
+		var items []Blocker
+		for _, item := range dl.Items {
+			if pp, ok := item.(*Paragraph); ok {
+				items = append(items, pp.terms()...)
+			} else {
+				items = append(items, item)
+			}
+		}
+		dl.Items = items
+		if len(items) > 0 {
+			dl.Start = spanOf(items[0]).Start
+		}
+		return WalkContinue
+	})
+}
+
+// terms makes a term of each line of the paragraph.
+func (pp *Paragraph) terms() []Blocker {
+	raw := []rune(strings.Join(pp.texts, ""))
+	m := newSourceMap(raw, pp.starts)
+
+	var terms []Blocker
+	for start := 0; start < len(raw); {
+		end := start
+		for end < len(raw) && raw[end] != '\n' {
+			end++
+		}
+		if text := string(raw[start:end]); strings.TrimSpace(text) != "" {
+			terms = append(terms, &DefinitionTerm{
+				Span:   m.span(start, end),
+				text:   text,
+				starts: []Position{m[start]},
+			})
+		}
+		start = end + 1
+	}
+	return terms
+}
+
+// deduceIsTight deduces whether the list is tight, as List.deduceIsTight does.
+func (dl *DefinitionList) deduceIsTight() {
+	var setListBlankLine *BlankLine
+
+	for _, item := range dl.Items {
+		switch t := item.(type) {
+		case *DefinitionTerm:
+			// Blank lines before terms do not count.
+			setListBlankLine = nil
+		case *DefinitionDescription:
+			if setListBlankLine != nil {
+				dl.Tight = false
+				return
+			}
+		case *BlankLine:
+			if setListBlankLine == nil {
+				setListBlankLine = t
+			}
+		}
+
+		if dd, ok := item.(*DefinitionDescription); ok {
+			var setItemBlock Blocker
+			var setItemBlankLine *BlankLine
+
+			for _, block := range dd.blocks {
+				switch t := block.(type) {
+				default:
+					if setItemBlankLine != nil {
+						dl.Tight = false
+						return
+					}
+					if setItemBlock == nil {
+						setItemBlock = t
+					}
+				case *BlankLine:
+					if setItemBlock != nil && setItemBlankLine == nil {
+						setItemBlankLine = t
+					}
+				}
+			}
+		}
+	}
+	dl.Tight = true
+}
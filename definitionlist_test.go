@@ -0,0 +1,60 @@
+package taomd
+
+import (
+	"testing"
+)
+
+func TestDefinitionList(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "Apple\n:   A fruit.\n:   A company.\n\nOrange\n:   Another fruit.\n",
+			HTML:     "<dl>\n<dt>Apple</dt>\n<dd>A fruit.</dd>\n<dd>A company.</dd>\n<dt>Orange</dt>\n<dd>Another fruit.</dd>\n</dl>\n",
+			Section:  "Definition lists",
+		},
+		{
+			Markdown: "Term 1\nTerm 2\n: desc\n",
+			HTML:     "<dl>\n<dt>Term 1</dt>\n<dt>Term 2</dt>\n<dd>desc</dd>\n</dl>\n",
+			Section:  "Definition lists",
+		},
+		{
+			Markdown: "Term\n\n: para 1\n\n    para 2\n",
+			HTML:     "<dl>\n<dt>Term</dt>\n<dd>\n<p>para 1</p>\n<p>para 2</p>\n</dd>\n</dl>\n",
+			Section:  "Definition lists",
+		},
+		{
+			Markdown: "Term\n: desc\nlazy\n",
+			HTML:     "<dl>\n<dt>Term</dt>\n<dd>desc\nlazy</dd>\n</dl>\n",
+			Section:  "Definition lists",
+		},
+		{
+			Markdown: "a\n:\n\nb\n",
+			HTML:     "<dl>\n<dt>a</dt>\n<dd></dd>\n</dl>\n<p>b</p>\n",
+			Section:  "Definition lists",
+		},
+		{
+			Markdown: "a\n: \n",
+			HTML:     "<dl>\n<dt>a</dt>\n<dd></dd>\n</dl>\n",
+			Section:  "Definition lists",
+		},
+		{
+			Markdown: ": alone\n",
+			HTML:     "<p>: alone</p>\n",
+			Section:  "Definition lists",
+		},
+		{
+			Markdown: "- Term [a]\n  : desc\n\n[a]: /u\n",
+			HTML:     "<ul>\n<li>\n<dl>\n<dt>Term <a href=\"/u\">a</a></dt>\n<dd>desc</dd>\n</dl>\n</li>\n</ul>\n",
+			Section:  "Definition lists",
+		},
+		{
+			Markdown: "[a]: /u\n: not a description\n",
+			HTML:     "<p>: not a description</p>\n",
+			Section:  "Link reference definitions",
+		},
+		{
+			Markdown: "[a]: /1\n\n[a]: /2\nTerm [a]\n: desc\n",
+			HTML:     "<dl>\n<dt>Term <a href=\"/1\">a</a></dt>\n<dd>desc</dd>\n</dl>\n",
+			Section:  "Link reference definitions",
+		},
+	}, WithExtensions(ExtDefinitionList))
+}
@@ -0,0 +1,92 @@
+package taomd
+
+import (
+	"strconv"
+	"strings"
+)
+
+// An Emoji is an emoji written as its shortcode, as in ":smile:".
+//
+// Shortcodes are those of GitHub. Unknown ones are left as texts, and so are
+// those right after ASCII letters or digits, as in "key:smile:".
+type Emoji struct {
+	Span
+
+	// The shortcode without colons, as in "smile".
+	Shortcode string
+
+	// The Unicode sequence of the emoji, as in "😄".
+	Emoji string
+}
+
+// TextContent returns the Unicode sequence of the emoji.
+func (e *Emoji) TextContent() string {
+	return e.Emoji
+}
+
+// Codepoints returns the code points of the Unicode sequence of the emoji
+// in lowercase hexadecimal, separated by hyphens (-), as in "1f604",
+// which is how emoji images are commonly named.
+func (e *Emoji) Codepoints() string {
+	var codepoints []string
+	for _, r := range e.Emoji {
+		codepoints = append(codepoints, strconv.FormatInt(int64(r), 16))
+	}
+	return strings.Join(codepoints, "-")
+}
+
+// tryParseEmoji parses an emoji shortcode at the start of c, and returns what follows it.
+//
+// A shortcode consists of lowercase ASCII letters, digits, "_", "+" and "-",
+// enclosed in colons (:).
+func tryParseEmoji(c []rune) ([]rune, *Emoji) {
+	if len(c) < 3 || c[0] != ':' {
+		return c, nil
+	}
+	for i := 1; i < len(c); i++ {
+		r := c[i]
+		switch {
+		case r == ':':
+			shortcode := string(c[1:i])
+			emoji, ok := emojis[shortcode]
+			if !ok {
+				return c, nil
+			}
+			return c[i+1:], &Emoji{
+				Shortcode: shortcode,
+				Emoji:     emoji,
+			}
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9', r == '_', r == '+', r == '-':
+		default:
+			return c, nil
+		}
+	}
+	return c, nil
+}
+
+// isEmojiShortcode tells whether s starts with a known emoji shortcode.
+func isEmojiShortcode(s string) bool {
+	if len(s) < 3 || s[0] != ':' {
+		return false
+	}
+	i := strings.IndexByte(s[1:], ':')
+	if i < 0 {
+		return false
+	}
+	_, ok := emojis[s[1:i+1]]
+	return ok
+}
+
+// WithEmojiImages renders emojis as images, as in
+//
+//	<img class="emoji" src="..." alt="😄" title=":smile:" />
+//
+// instead of as their Unicode sequences. The source of the image is made of
+// urlTemplate, in which "{shortcode}" is replaced by the shortcode and
+// "{codepoints}" by Emoji.Codepoints, as in
+// "https://cdn.jsdelivr.net/gh/twitter/twemoji@latest/assets/72x72/{codepoints}.png".
+func WithEmojiImages(urlTemplate string) RendererOption {
+	return func(r *Renderer) {
+		r.emojiURL = urlTemplate
+	}
+}
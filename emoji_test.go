@@ -0,0 +1,51 @@
+package taomd
+
+import (
+	"strings"
+	"testing"
+)
+
+var emojiExamples = []*Example{
+	{
+		Markdown: "I :heart: Go :+1:\n",
+		HTML:     "<p>I ❤️ Go 👍</p>\n",
+		Section:  "Emoji",
+	},
+	{
+		Markdown: ":not_an_emoji: and :smile\n",
+		HTML:     "<p>:not_an_emoji: and :smile</p>\n",
+		Section:  "Emoji",
+	},
+	{
+		Markdown: "key:smile: and (:smile:)\n",
+		HTML:     "<p>key:smile: and (😄)</p>\n",
+		Section:  "Emoji",
+	},
+	{
+		Markdown: "`:smile:` \\:smile:\n",
+		HTML:     "<p><code>:smile:</code> :smile:</p>\n",
+		Section:  "Emoji",
+	},
+	{
+		Markdown: ":family_man_woman_girl:\n",
+		HTML:     "<p>👨\u200d👩\u200d👧</p>\n",
+		Section:  "Emoji",
+	},
+}
+
+func TestEmoji(t *testing.T) {
+	testExamples(t, emojiExamples, WithExtensions(ExtEmoji))
+}
+
+func TestEmojiRoundTrip(t *testing.T) {
+	testRoundTrip(t, emojiExamples, WithExtensions(ExtEmoji))
+}
+
+func TestEmojiImages(t *testing.T) {
+	doc := Parse(strings.NewReader("I :heart: it\n"), WithExtensions(ExtEmoji))
+	r := NewRenderer(WithEmojiImages("/emoji/{shortcode}-{codepoints}.png"))
+	want := "<p>I <img class=\"emoji\" src=\"/emoji/heart-2764-fe0f.png\" alt=\"❤️\" title=\":heart:\" /> it</p>\n"
+	if html := r.Render(doc); html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+}
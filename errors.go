@@ -0,0 +1,76 @@
+package taomd
+
+import (
+	"fmt"
+)
+
+// A ReadError is returned when the markdown input cannot be read.
+type ReadError struct {
+	Err error
+}
+
+func (e *ReadError) Error() string {
+	return "taomd: read input: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying read error.
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// A SizeError is returned when the markdown input is larger than
+// the limit set by WithMaxSize.
+type SizeError struct {
+	Limit int
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("taomd: input exceeds %d bytes", e.Limit)
+}
+
+// A FrontMatterError is returned when the front matter cannot be decoded.
+// ParseReader still returns the document with it, which has the raw front
+// matter but no data, and a formatter may write it back as it is.
+type FrontMatterError struct {
+	Format FrontMatterFormat
+	Err    error
+}
+
+func (e *FrontMatterError) Error() string {
+	return "taomd: decode " + e.Format.String() + " front matter: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *FrontMatterError) Unwrap() error {
+	return e.Err
+}
+
+// An InternalError reports a broken invariant inside the parser or the renderer.
+// It is a bug in taomd, not in the document, but it is reported instead of
+// panicking so that a single document cannot take down the whole process.
+type InternalError struct {
+	Msg string
+}
+
+func (e *InternalError) Error() string {
+	return "taomd: internal error: " + e.Msg
+}
+
+// internalErrorf makes an InternalError.
+func internalErrorf(format string, args ...interface{}) *InternalError {
+	return &InternalError{
+		Msg: fmt.Sprintf(format, args...),
+	}
+}
+
+// recoverError converts a recovered panic into an error stored in *err.
+// It must be deferred directly, as in `defer recoverError(&err)`.
+func recoverError(err *error) {
+	if e := recover(); e != nil {
+		if ie, ok := e.(*InternalError); ok {
+			*err = ie
+			return
+		}
+		*err = internalErrorf("%v", e)
+	}
+}
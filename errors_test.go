@@ -0,0 +1,126 @@
+package taomd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// errReader reads s, then fails with err.
+type errReader struct {
+	s   string
+	err error
+}
+
+func (r *errReader) Read(b []byte) (int, error) {
+	if r.s == "" {
+		return 0, r.err
+	}
+	n := copy(b, r.s)
+	r.s = r.s[n:]
+	return n, nil
+}
+
+func TestReadError(t *testing.T) {
+	failed := errors.New("failed")
+	doc, err := ParseReader(&errReader{s: "# foo\n\nbar\n", err: failed})
+	if e, ok := err.(*ReadError); !ok || e.Err != failed {
+		t.Fatalf("error: %v", err)
+	}
+	if html, want := Render(doc), "<h1>foo</h1>\n<p>bar</p>\n"; html != want {
+		t.Errorf("want what has been read:\n%s\ngiven:\n%s", want, html)
+	}
+	if Parse(&errReader{s: "foo\n", err: failed}) == nil {
+		t.Errorf("Parse returns nil")
+	}
+}
+
+func TestSizeError(t *testing.T) {
+	doc, err := ParseReader(strings.NewReader("foo\n\n"+strings.Repeat("bar\n", 100)), WithMaxSize(5))
+	if e, ok := err.(*SizeError); !ok || e.Limit != 5 {
+		t.Fatalf("error: %v", err)
+	}
+	if html, want := Render(doc), "<p>foo</p>\n"; html != want {
+		t.Errorf("want what has been read:\n%s\ngiven:\n%s", want, html)
+	}
+}
+
+func TestInternalError(t *testing.T) {
+	// A panic in the parser is recovered as an *InternalError.
+	panicking := WithTransformer(func(doc *Document) error {
+		var blocks []Blocker
+		_ = blocks[len(doc.Blocks())]
+		return nil
+	}, 0)
+
+	doc, err := ParseReader(strings.NewReader("foo\n"), panicking)
+	if _, ok := err.(*InternalError); !ok {
+		t.Fatalf("error: %v", err)
+	}
+	if doc == nil || len(doc.Blocks()) != 0 {
+		t.Errorf("ParseReader: want an empty document, given %v", doc)
+	}
+	if doc := Parse(strings.NewReader("foo\n"), panicking); doc == nil || len(doc.Blocks()) != 0 {
+		t.Errorf("Parse: want an empty document, given %v", doc)
+	}
+}
+
+// Inputs that made the parser panic, and so lose the whole document.
+func TestInternalErrorInputs(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: " ",
+			HTML:     "",
+			Section:  "Blank lines",
+		},
+		{
+			Markdown: "a\n ",
+			HTML:     "<p>a</p>\n",
+			Section:  "Blank lines",
+		},
+		{
+			Markdown: "\t",
+			HTML:     "",
+			Section:  "Blank lines",
+		},
+		{
+			Markdown: "-   ",
+			HTML:     "<ul>\n<li></li>\n</ul>\n",
+			Section:  "Setext headings",
+		},
+		{
+			Markdown: "[a](\n",
+			HTML:     "<p>[a](</p>\n",
+			Section:  "Links",
+		},
+		{
+			Markdown: "[x](]\n",
+			HTML:     "<p>[x](]</p>\n",
+			Section:  "Links",
+		},
+		{
+			Markdown: "[a](\n\n[a]: /u\n",
+			HTML:     "<p><a href=\"/u\">a</a>(</p>\n",
+			Section:  "Links",
+		},
+	}, WithExtensions(CommonMark))
+
+	for _, markdown := range []string{"```\n ", "$$\n "} {
+		if _, err := ParseReader(strings.NewReader(markdown), WithExtensions(ExtMath)); err != nil {
+			t.Errorf("%q: %v", markdown, err)
+		}
+	}
+}
+
+func TestTransformerError(t *testing.T) {
+	failed := errors.New("failed")
+	doc, err := ParseReader(strings.NewReader("foo\n"), WithTransformer(func(doc *Document) error {
+		return failed
+	}, 0))
+	if err != failed {
+		t.Fatalf("error: %v", err)
+	}
+	if html, want := Render(doc), "<p>foo</p>\n"; html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+}
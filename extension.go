@@ -0,0 +1,93 @@
+package taomd
+
+// An Extension is a set of syntax extensions to CommonMark.
+type Extension uint
+
+// Syntax extensions.
+const (
+	// ExtTable parses GFM tables.
+	ExtTable Extension = 1 << iota
+
+	// ExtStrikethrough parses GFM strikethrough.
+	ExtStrikethrough
+
+	// ExtTaskList parses GFM task list items.
+	ExtTaskList
+
+	// ExtAutolink parses GFM extended autolinks: www., http://, https://,
+	// ftp:// and email addresses in text without angle brackets.
+	ExtAutolink
+
+	// ExtTagFilter filters out the GFM disallowed raw HTML tags when rendering.
+	ExtTagFilter
+
+	// ExtFootnote parses footnote references and definitions, as in
+	// "text[^1]" and "[^1]: note", and renders the referenced footnotes
+	// at the end of the document. It is not a part of GFM.
+	ExtFootnote
+
+	// ExtFrontMatter parses YAML, TOML or JSON front matter at the start
+	// of the document, instead of leaving it to the block parser.
+	// It is not a part of GFM.
+	ExtFrontMatter
+
+	// ExtAttributes parses attribute lists, as in "{#id .class key=value}",
+	// which give HTML attributes to headings, fenced code blocks, links,
+	// images, and the blocks right after them. It is not a part of GFM.
+	ExtAttributes
+
+	// ExtMath parses TeX math, as in "$x^2$", "$$x^2$$", lines fenced by "$$",
+	// and fenced code blocks with the info string "math", and renders it
+	// for KaTeX or MathJax to typeset. It is not a part of GFM.
+	ExtMath
+
+	// ExtEmoji parses emoji shortcodes, as in ":smile:", which are rendered
+	// as the Unicode sequences of the emojis, or as images by WithEmojiImages.
+	// It is not a part of GFM.
+	ExtEmoji
+
+	// ExtDefinitionList parses definition lists, in which each term is a line
+	// followed by descriptions starting with colons (:), as in "Term\n: Description".
+	// It is not a part of GFM.
+	ExtDefinitionList
+
+	// ExtAlert makes alerts of block quotes that start with lines of
+	// only alert kinds, as in "> [!NOTE]", as GitHub does.
+	// It is not a part of GFM.
+	ExtAlert
+
+	// ExtFencedDiv parses blocks fenced by lines of colons, as in "::: warning",
+	// which are rendered as <div>s, or by the RenderFuncs of their names.
+	// It is not a part of GFM.
+	ExtFencedDiv
+)
+
+// Sets of extensions.
+const (
+	// CommonMark enables no extensions, for strict CommonMark parsing.
+	CommonMark Extension = 0
+
+	// GFM enables the extensions of GitHub Flavored Markdown.
+	GFM = ExtTable | ExtStrikethrough | ExtTaskList | ExtAutolink | ExtTagFilter
+
+	// DefaultExtensions are enabled if WithExtensions is not given.
+	// GFM is not, for documents are parsed as CommonMark unless asked.
+	DefaultExtensions = CommonMark
+)
+
+// WithExtensions enables exts, and only exts, instead of DefaultExtensions.
+func WithExtensions(exts Extension) Option {
+	return func(p *Parser) {
+		p.extensions = exts
+	}
+}
+
+// Extensions returns the extensions the document is parsed with.
+func (doc *Document) Extensions() Extension {
+	return doc.extensions
+}
+
+// enabled tells whether ext is enabled.
+func (p *Parser) enabled(ext Extension) bool {
+	return p.extensions&ext != 0
+}
@@ -0,0 +1,219 @@
+package taomd
+
+import (
+	"strings"
+)
+
+// A FencedDiv is a block of any blocks fenced by lines of three or more colons (:),
+// as Pandoc has for fenced divs, and markdown-it-container has:
+//
+//	::: warning "Be careful"
+//	This is a *warning*.
+//	:::
+//
+// The opening fence is followed by a name, a title and an attribute list,
+// any of which may be left out, but not all of them, as in "::: {.class #id}".
+// The fenced div is closed by a fence of only colons, at least as many as
+// the opening fence has, or by the end of its parent block.
+// Fenced divs can be nested, with the outer ones fenced by longer fences
+// for readability.
+type FencedDiv struct {
+	Span
+	Attributes
+
+	// The info string after the opening fence, without the attribute list.
+	Info string
+
+	// The first word of the info string, as in "warning".
+	Name string
+
+	// The rest of the info string, without enclosing quotes, as in "Be careful".
+	Title string
+
+	FenceLength int
+
+	blocks []Blocker
+	closed bool
+}
+
+// Blocks returns the blocks in the fenced div.
+func (d *FencedDiv) Blocks() []Blocker {
+	return d.blocks
+}
+
+// SetBlocks replaces the blocks in the fenced div.
+func (d *FencedDiv) SetBlocks(blocks []Blocker) {
+	d.blocks = blocks
+}
+
+// Children returns the blocks in the fenced div.
+func (d *FencedDiv) Children() []Node {
+	return blocksToNodes(d.blocks)
+}
+
+func (d *FencedDiv) AddLine(p *Parser, s []rune) bool {
+	if d.closed {
+		return false
+	}
+
+	// The closing fence is of the innermost fenced div, and is
+	// not one in a fenced code block, even in a list item.
+	if !hasOpenFence(d.blocks, s) && d.isClosingFence(s) {
+		d.closed = true
+		return true
+	}
+
+	if p.addLine(&d.blocks, s) {
+		p.tryMergeSetextHeading(&d.blocks)
+		p.tryMergeTableHeader(&d.blocks)
+	}
+	return true
+}
+
+// hasOpenFence tells whether s is a line of a fenced div or a fenced code
+// block that is not closed, which is the last of blocks or the last open
+// block in it, as in a list item. Such a line is no closing fence.
+func hasOpenFence(blocks []Blocker, s []rune) bool {
+	if len(blocks) == 0 {
+		return false
+	}
+	switch typed := blocks[len(blocks)-1].(type) {
+	case *FencedDiv:
+		return !typed.closed
+	case *CodeBlock:
+		return typed.isFenced() && !typed.closed
+	case *BlockQuote:
+		_, s = skipPrefixSpaces(s, 3)
+		if !is(s, '>') {
+			return false
+		}
+		return hasOpenFence(typed.blocks, skipIf(s[1:], ' '))
+	case *List:
+		if typed.closed {
+			return false
+		}
+		for i := len(typed.Items) - 1; i >= 0; i-- {
+			if item, ok := typed.Items[i].(*ListItem); ok {
+				return hasOpenFence([]Blocker{item}, s)
+			}
+		}
+	case *ListItem:
+		if typed.closed {
+			return false
+		}
+		_, n := peekSpaces(s, typed.prefixSpaces)
+		s = s[n:]
+		if _, n = peekSpaces(s, typed.suffixSpaces); n != typed.suffixSpaces {
+			return false
+		}
+		return hasOpenFence(typed.blocks, s[n:])
+	case *FootnoteDefinition:
+		if _, n := peekSpaces(s, footnoteIndent); !typed.closed && n == footnoteIndent {
+			return hasOpenFence(typed.blocks, s[n:])
+		}
+	case *DefinitionList:
+		dd := typed.lastDescription()
+		if _, n := peekSpaces(s, descriptionIndent); dd != nil && !dd.closed && n == descriptionIndent {
+			return hasOpenFence(dd.blocks, s[n:])
+		}
+	}
+	return false
+}
+
+func (d *FencedDiv) isClosingFence(s []rune) bool {
+	_, s = skipPrefixSpaces(s, 3)
+	n := 0
+	for n < len(s) && s[n] == ':' {
+		n++
+	}
+	return n >= d.FenceLength && isBlankLine(s[n:])
+}
+
+// tryParseFencedDivStart parses the opening fence of a fenced div,
+// which is indented no more than three spaces.
+func tryParseFencedDivStart(s []rune) *FencedDiv {
+	n := 0
+	for n < len(s) && s[n] == ':' {
+		n++
+	}
+	if n < 3 {
+		return nil
+	}
+
+	// Colons after the info string are optional, as Pandoc allows.
+	info := strings.TrimSpace(string(s[n:]))
+	info = strings.TrimSpace(strings.TrimRight(info, ":"))
+	info, attrs := trailingAttributes(info)
+	info = strings.TrimSpace(info)
+	if info == "" && attrs == nil {
+		return nil
+	}
+
+	d := &FencedDiv{
+		Attributes:  attrs,
+		Info:        info,
+		Name:        info,
+		FenceLength: n,
+	}
+	if i := strings.IndexAny(info, " \t"); i >= 0 {
+		d.Name = info[:i]
+		d.Title = strings.TrimSpace(info[i:])
+		if title := []rune(d.Title); len(title) > 0 && (title[0] == '"' || title[0] == '\'') {
+			if n, value := parseAttributeValue(title); n == len(title) {
+				d.Title = value
+			}
+		}
+	}
+	return d
+}
+
+// WithFencedDivRenderFunc renders fenced divs of name by fn,
+// instead of by the RenderFunc for KindFencedDiv, as in:
+//
+//	WithFencedDivRenderFunc("details", RenderDetails)
+func WithFencedDivRenderFunc(name string, fn RenderFunc) RendererOption {
+	return func(r *Renderer) {
+		r.RegisterFencedDiv(name, fn)
+	}
+}
+
+// RegisterFencedDiv renders fenced divs of name by fn,
+// like Register does for kinds of nodes. A nil fn restores the default.
+//
+// RegisterFencedDiv must not be called while the Renderer is rendering.
+func (r *Renderer) RegisterFencedDiv(name string, fn RenderFunc) {
+	if fn == nil {
+		delete(r.divFuncs, name)
+		return
+	}
+	r.divFuncs[name] = fn
+}
+
+// RenderDetails is a RenderFunc for fenced divs, which renders them as
+// disclosure widgets, with their titles, or their names if they have none,
+// as the summaries:
+//
+//	<details>
+//	<summary>Title</summary>
+//	...
+//	</details>
+func RenderDetails(w *Writer, node Node, entering bool) (WalkStatus, error) {
+	d, ok := node.(*FencedDiv)
+	if !ok {
+		return w.RenderDefault(node, entering)
+	}
+	if !entering {
+		w.WriteString("</details>\n")
+		return WalkContinue, nil
+	}
+	summary := d.Title
+	if summary == "" {
+		summary = d.Name
+	}
+	w.WriteString("<details")
+	w.WriteAttributes(d.Attributes)
+	w.WriteString(">\n<summary>")
+	w.WriteEscaped(summary)
+	w.WriteString("</summary>\n")
+	return WalkContinue, nil
+}
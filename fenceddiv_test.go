@@ -0,0 +1,80 @@
+package taomd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFencedDiv(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "::: warning\nThis is a *warning*.\n:::\n",
+			HTML:     "<div class=\"warning\">\n<p>This is a <em>warning</em>.</p>\n</div>\n",
+			Section:  "Fenced divs",
+		},
+		{
+			Markdown: "::: {.note #n}\ntext\n:::\n",
+			HTML:     "<div id=\"n\" class=\"note\">\n<p>text</p>\n</div>\n",
+			Section:  "Fenced divs",
+		},
+		{
+			Markdown: "::: a ::::\ntext\n::::\n",
+			HTML:     "<div class=\"a\">\n<p>text</p>\n</div>\n",
+			Section:  "Fenced divs",
+		},
+		{
+			Markdown: ":::\ntext\n:::\n",
+			HTML:     "<p>:::\ntext\n:::</p>\n",
+			Section:  "Fenced divs",
+		},
+		{
+			Markdown: "::: a\ntext\n",
+			HTML:     "<div class=\"a\">\n<p>text</p>\n</div>\n",
+			Section:  "Fenced divs",
+		},
+		{
+			Markdown: "::: a\n    :::\n:::\n",
+			HTML:     "<div class=\"a\">\n<pre><code>:::\n</code></pre>\n</div>\n",
+			Section:  "Fenced divs",
+		},
+		{
+			Markdown: ":::: outer\n::: inner\ntext\n:::\n::::\n",
+			HTML:     "<div class=\"outer\">\n<div class=\"inner\">\n<p>text</p>\n</div>\n</div>\n",
+			Section:  "Nesting",
+		},
+		{
+			Markdown: "::: a\n```\n:::\n```\n:::\n",
+			HTML:     "<div class=\"a\">\n<pre><code>:::\n</code></pre>\n</div>\n",
+			Section:  "Nesting",
+		},
+		{
+			Markdown: "::: a\n- item\n  ```\n  :::\n  ```\n:::\n",
+			HTML:     "<div class=\"a\">\n<ul>\n<li>item\n<pre><code>:::\n</code></pre>\n</li>\n</ul>\n</div>\n",
+			Section:  "Nesting",
+		},
+		{
+			Markdown: "::: a\n> ```\n> :::\n> ```\n:::\nafter\n",
+			HTML:     "<div class=\"a\">\n<blockquote>\n<pre><code>:::\n</code></pre>\n</blockquote>\n</div>\n<p>after</p>\n",
+			Section:  "Nesting",
+		},
+		{
+			Markdown: "::: a\n- ```\n:::\nafter\n",
+			HTML:     "<div class=\"a\">\n<ul>\n<li>\n<pre><code></code></pre>\n</li>\n</ul>\n</div>\n<p>after</p>\n",
+			Section:  "Nesting",
+		},
+		{
+			Markdown: ":::: a\n::: b\n- x\n  ::: c\n  y\n  :::\n:::\n::::\n",
+			HTML:     "<div class=\"a\">\n<div class=\"b\">\n<ul>\n<li>x\n<div class=\"c\">\n<p>y</p>\n</div>\n</li>\n</ul>\n</div>\n</div>\n",
+			Section:  "Nesting",
+		},
+	}, WithExtensions(ExtFencedDiv|ExtAttributes))
+}
+
+func TestFencedDivRenderFunc(t *testing.T) {
+	doc := Parse(strings.NewReader("::: details \"Be careful\"\ntext\n:::\n"), WithExtensions(ExtFencedDiv))
+	r := NewRenderer(WithFencedDivRenderFunc("details", RenderDetails))
+	want := "<details>\n<summary>Be careful</summary>\n<p>text</p>\n</details>\n"
+	if html := r.Render(doc); html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+}
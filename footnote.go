@@ -0,0 +1,204 @@
+package taomd
+
+import (
+	"strings"
+)
+
+// A FootnoteDefinition defines the content of a footnote, which is
+// referenced by its label elsewhere in the document, as in:
+//
+//	Here is a footnote reference.[^1]
+//
+//	[^1]: Here is the footnote.
+//
+//	    Indented paragraphs belong to the footnote too.
+//
+// A definition that is never referenced is not rendered.
+type FootnoteDefinition struct {
+	Span
+
+	// The label, as it is written, without the enclosing "[^" and "]".
+	Label string
+
+	// The number of the footnote, in the order of the first references
+	// to footnotes, or 0 if it is not referenced.
+	Index int
+
+	// How many times the footnote is referenced.
+	RefCount int
+
+	blocks []Blocker
+	closed bool
+}
+
+// A FootnoteReference is a reference to a footnote.
+// "[^label]" is a reference only if a footnote with a matching label is defined.
+type FootnoteReference struct {
+	Span
+
+	// The label, as it is written.
+	Label string
+
+	// The number of the footnote referenced.
+	Index int
+
+	// The reference is the Ref-th one to the footnote, starting from 1.
+	Ref int
+}
+
+// Blocks returns the blocks in the footnote.
+func (fd *FootnoteDefinition) Blocks() []Blocker {
+	return fd.blocks
+}
+
+// SetBlocks replaces the blocks in the footnote.
+func (fd *FootnoteDefinition) SetBlocks(blocks []Blocker) {
+	fd.blocks = blocks
+}
+
+// Children returns the blocks in the footnote.
+func (fd *FootnoteDefinition) Children() []Node {
+	return blocksToNodes(fd.blocks)
+}
+
+// Children returns nil.
+func (fr *FootnoteReference) Children() []Node {
+	return nil
+}
+
+// TextContent returns the label as it is written.
+func (fr *FootnoteReference) TextContent() string {
+	return "[^" + fr.Label + "]"
+}
+
+// Footnotes returns the referenced footnote definitions in the order of
+// their numbers. Definitions that are not referenced are left out.
+func (doc *Document) Footnotes() []*FootnoteDefinition {
+	return doc.footnoteList
+}
+
+// The content of a footnote continues on lines indented by 4 spaces,
+// and on lazy continuation lines of a paragraph.
+const footnoteIndent = 4
+
+func (fd *FootnoteDefinition) AddLine(p *Parser, s []rune) bool {
+	if fd.closed {
+		return false
+	}
+
+	if len(s) == 1 && s[0] == '\n' {
+		if len(fd.blocks) > 0 && fd.blocks[len(fd.blocks)-1].AddLine(p, s) {
+			return true
+		}
+		fd.blocks = append(fd.blocks, p.newBlankLine(s))
+		return true
+	}
+
+	if _, n := peekSpaces(s, footnoteIndent); n == footnoteIndent {
+		if p.addLine(&fd.blocks, s[n:]) {
+			p.tryMergeSetextHeading(&fd.blocks)
+			p.tryMergeTableHeader(&fd.blocks)
+			return true
+		}
+	} else if fd.addLaziness(p, s) {
+		return true
+	}
+
+	fd.closed = true
+	return false
+}
+
+func (fd *FootnoteDefinition) addLaziness(p *Parser, s []rune) bool {
+	if len(fd.blocks) == 0 {
+		return false
+	}
+	switch typed := fd.blocks[len(fd.blocks)-1].(type) {
+	case *List:
+		return typed.addLaziness(p, s)
+	case *BlockQuote:
+		return typed.addLaziness(p, s)
+	case *Paragraph:
+		return typed.addLaziness(p, s)
+	case *DefinitionList:
+		return typed.addLaziness(p, s)
+	}
+	return false
+}
+
+// tryParseFootnoteDefinition parses the start of a footnote definition,
+// which is a footnote label followed by a colon (:). What follows the colon
+// on the same line is the start of the content.
+func (p *Parser) tryParseFootnoteDefinition(s []rune) *FootnoteDefinition {
+	n, label := parseFootnoteLabel(s)
+	if n == 0 || n >= len(s) || s[n] != ':' {
+		return nil
+	}
+
+	fd := &FootnoteDefinition{
+		Label: label,
+	}
+	p.startBlock(fd, s)
+	p.tip = fd
+
+	_, rest := skipPrefixSpaces(s[n+1:], -1)
+	if !isBlankLine(rest) {
+		p.addLine(&fd.blocks, rest)
+	}
+	return fd
+}
+
+// parseFootnoteLabel parses a footnote label, "[^label]", at the start of s,
+// and returns its length and the label, or 0 if there is none.
+//
+// The label must not be empty, and must not contain white spaces or brackets.
+func parseFootnoteLabel(s []rune) (int, string) {
+	if len(s) < 3 || s[0] != '[' || s[1] != '^' {
+		return 0, ""
+	}
+	for i := 2; i < len(s) && i <= 1000; i++ {
+		switch s[i] {
+		case ']':
+			if i == 2 {
+				return 0, ""
+			}
+			return i + 1, string(s[2:i])
+		case '[', ' ', '\t', '\n', '\r':
+			return 0, ""
+		}
+	}
+	return 0, ""
+}
+
+// parseFootnotes collects the footnote definitions by their labels.
+// If there are several definitions with matching labels, the first one takes precedence.
+func (doc *Document) parseFootnotes() {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		if fd, ok := n.(*FootnoteDefinition); ok && entering {
+			label := strings.ToLower(fd.Label)
+			if _, ok := doc.footnotes[label]; !ok {
+				doc.footnotes[label] = fd
+			}
+		}
+		return WalkContinue
+	})
+}
+
+// refFootnote makes a reference to the footnote labeled label,
+// numbering the footnote if it is referenced for the first time.
+// It returns nil if there is no such footnote.
+func (doc *Document) refFootnote(label string) *FootnoteReference {
+	fd, ok := doc.footnotes[strings.ToLower(label)]
+	if !ok {
+		return nil
+	}
+	if fd.Index == 0 {
+		doc.footnoteList = append(doc.footnoteList, fd)
+		fd.Index = len(doc.footnoteList)
+	}
+	fd.RefCount++
+	return &FootnoteReference{
+		Label: label,
+		Index: fd.Index,
+		Ref:   fd.RefCount,
+	}
+}
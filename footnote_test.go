@@ -0,0 +1,46 @@
+package taomd
+
+import (
+	"testing"
+)
+
+var footnoteExamples = []*Example{
+	{
+		Markdown: "Here is a note.[^1]\n\n[^1]: The note.\n",
+		HTML:     "<p>Here is a note.<sup class=\"footnote-ref\"><a href=\"#fn-1\" id=\"fnref-1\" data-footnote-ref>1</a></sup></p>\n<section class=\"footnotes\" data-footnotes>\n<ol>\n<li id=\"fn-1\">\n<p>The note. <a href=\"#fnref-1\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"1\" aria-label=\"Back to reference 1\">↩</a></p>\n</li>\n</ol>\n</section>\n",
+		Section:  "Footnotes",
+	},
+	{
+		Markdown: "A[^a] and B[^b] and A again[^a].\n\n[^b]: Bee.\n[^a]: Ay.\n",
+		HTML:     "<p>A<sup class=\"footnote-ref\"><a href=\"#fn-a\" id=\"fnref-a\" data-footnote-ref>1</a></sup> and B<sup class=\"footnote-ref\"><a href=\"#fn-b\" id=\"fnref-b\" data-footnote-ref>2</a></sup> and A again<sup class=\"footnote-ref\"><a href=\"#fn-a\" id=\"fnref-a-2\" data-footnote-ref>1</a></sup>.</p>\n<section class=\"footnotes\" data-footnotes>\n<ol>\n<li id=\"fn-a\">\n<p>Ay. <a href=\"#fnref-a\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"1\" aria-label=\"Back to reference 1\">↩</a> <a href=\"#fnref-a-2\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"1-2\" aria-label=\"Back to reference 1-2\">↩<sup class=\"footnote-ref\">2</sup></a></p>\n</li>\n<li id=\"fn-b\">\n<p>Bee. <a href=\"#fnref-b\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"2\" aria-label=\"Back to reference 2\">↩</a></p>\n</li>\n</ol>\n</section>\n",
+		Section:  "Footnotes",
+	},
+	{
+		Markdown: "x[^n]\n\n[^n]: para\n\n    second para\n\n        code\n",
+		HTML:     "<p>x<sup class=\"footnote-ref\"><a href=\"#fn-n\" id=\"fnref-n\" data-footnote-ref>1</a></sup></p>\n<section class=\"footnotes\" data-footnotes>\n<ol>\n<li id=\"fn-n\">\n<p>para</p>\n<p>second para</p>\n<pre><code>code\n</code></pre>\n<p><a href=\"#fnref-n\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"1\" aria-label=\"Back to reference 1\">↩</a></p>\n</li>\n</ol>\n</section>\n",
+		Section:  "Footnotes",
+	},
+	{
+		Markdown: "x[^n]\n\n[^n]: lazy\ncontinued\n",
+		HTML:     "<p>x<sup class=\"footnote-ref\"><a href=\"#fn-n\" id=\"fnref-n\" data-footnote-ref>1</a></sup></p>\n<section class=\"footnotes\" data-footnotes>\n<ol>\n<li id=\"fn-n\">\n<p>lazy\ncontinued <a href=\"#fnref-n\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"1\" aria-label=\"Back to reference 1\">↩</a></p>\n</li>\n</ol>\n</section>\n",
+		Section:  "Footnotes",
+	},
+	{
+		Markdown: "No def[^x].\n",
+		HTML:     "<p>No def[^x].</p>\n",
+		Section:  "Missing footnotes",
+	},
+	{
+		Markdown: "[^1]: Unused.\n\ntext\n",
+		HTML:     "<p>text</p>\n",
+		Section:  "Missing footnotes",
+	},
+}
+
+func TestFootnotes(t *testing.T) {
+	testExamples(t, footnoteExamples, WithExtensions(ExtFootnote))
+}
+
+func TestFootnotesRoundTrip(t *testing.T) {
+	testRoundTrip(t, footnoteExamples, WithExtensions(ExtFootnote))
+}
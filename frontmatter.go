@@ -0,0 +1,177 @@
+package taomd
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// FrontMatter is the metadata block at the very start of a document,
+// which is not a part of the markdown content, as in:
+//
+//	---
+//	title: Hello
+//	tags: [a, b]
+//	---
+//
+//	# Hello
+type FrontMatter struct {
+	Span
+
+	Format FrontMatterFormat
+
+	// The raw text of the metadata. For YAML and TOML, it is the lines
+	// between the delimiters; for JSON, it is the whole object.
+	Raw string
+
+	// The decoded metadata, or nil if it cannot be decoded,
+	// or if there is no decoder for its format.
+	Data map[string]interface{}
+}
+
+// FrontMatterFormat tells how front matter is written.
+type FrontMatterFormat int
+
+const (
+	// FrontMatterYAML is delimited by lines of "---".
+	FrontMatterYAML FrontMatterFormat = iota
+	// FrontMatterTOML is delimited by lines of "+++".
+	FrontMatterTOML
+	// FrontMatterJSON is an object which starts with a line of "{",
+	// and ends with a line of "}", or an object on the first line.
+	FrontMatterJSON
+)
+
+func (f FrontMatterFormat) String() string {
+	switch f {
+	case FrontMatterYAML:
+		return "YAML"
+	case FrontMatterTOML:
+		return "TOML"
+	case FrontMatterJSON:
+		return "JSON"
+	}
+	return "FrontMatterFormat(" + strconv.Itoa(int(f)) + ")"
+}
+
+// A FrontMatterDecoder decodes the raw text of front matter.
+type FrontMatterDecoder func(raw string) (map[string]interface{}, error)
+
+// WithFrontMatterDecoder decodes front matter of format by decode.
+//
+// JSON is decoded by encoding/json if no decoder is given. YAML and TOML
+// have no decoders, to keep their dependencies out, unless they are given,
+// as the package github.com/movsb/taomd/frontmatter does.
+func WithFrontMatterDecoder(format FrontMatterFormat, decode FrontMatterDecoder) Option {
+	return func(p *Parser) {
+		if p.frontMatterDecoders == nil {
+			p.frontMatterDecoders = make(map[FrontMatterFormat]FrontMatterDecoder)
+		}
+		p.frontMatterDecoders[format] = decode
+	}
+}
+
+// decodeJSON is the default decoder of JSON front matter.
+func decodeJSON(raw string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// FrontMatter returns the front matter of the document, or nil if there is none.
+func (doc *Document) FrontMatter() *FrontMatter {
+	return doc.frontMatter
+}
+
+// parseFrontMatter reads front matter from the first lines of ls.
+// If there is none, the lines read are put back for the block parser.
+//
+// Front matter is recognized only by its delimiters, except an object on
+// the first line, which has to be valid JSON. If it cannot be decoded,
+// it is still front matter, and a *FrontMatterError is returned with it.
+func (p *Parser) parseFrontMatter(ls *LineScanner) (*FrontMatter, error) {
+	if !ls.Scan() {
+		return nil, nil
+	}
+	lines := [][]rune{ls.Text()}
+
+	var fm FrontMatter
+	var closing string
+	switch first := trimLine(lines[0]); {
+	case first == "---":
+		fm.Format, closing = FrontMatterYAML, "---"
+	case first == "+++":
+		fm.Format, closing = FrontMatterTOML, "+++"
+	case first == "{":
+		fm.Format, closing = FrontMatterJSON, "}"
+	case strings.HasPrefix(first, "{") && strings.HasSuffix(first, "}") && json.Valid([]byte(first)):
+		fm.Format = FrontMatterJSON
+		return p.endFrontMatter(&fm, lines)
+	default:
+		ls.PutBack(lines[0])
+		return nil, nil
+	}
+
+	for ls.Scan() {
+		lines = append(lines, ls.Text())
+		if trimLine(ls.Text()) == closing {
+			return p.endFrontMatter(&fm, lines)
+		}
+	}
+
+	// Not closed, not front matter.
+	for i := len(lines) - 1; i >= 0; i-- {
+		ls.PutBack(lines[i])
+	}
+	return nil, nil
+}
+
+// endFrontMatter decodes fm from its lines, delimiters included,
+// and makes the block parser start right after them.
+func (p *Parser) endFrontMatter(fm *FrontMatter, lines [][]rune) (*FrontMatter, error) {
+	p.lineStart = Position{Line: 1, Column: 1}
+	for _, line := range lines {
+		p.lineStart = p.lineStart.advance(line)
+	}
+	p.line = []rune{}
+	return fm, fm.decode(lines, p.frontMatterDecoders[fm.Format])
+}
+
+// decode sets the span, the raw text and the data of fm from its lines,
+// delimiters included. The data is decoded by decode, if any.
+func (fm *FrontMatter) decode(lines [][]rune, decode FrontMatterDecoder) error {
+	fm.Start = Position{Line: 1, Column: 1}
+	fm.End = fm.Start
+	var sb strings.Builder
+	for i, line := range lines {
+		if i == len(lines)-1 {
+			fm.End = fm.End.advance([]rune(strings.TrimRight(string(line), "\r\n")))
+		} else {
+			fm.End = fm.End.advance(line)
+		}
+		if fm.Format == FrontMatterJSON || i > 0 && i < len(lines)-1 {
+			sb.WriteString(string(line))
+		}
+	}
+	fm.Raw = sb.String()
+
+	if decode == nil && fm.Format == FrontMatterJSON {
+		decode = decodeJSON
+	}
+	if decode == nil {
+		return nil
+	}
+	data, err := decode(fm.Raw)
+	if err != nil {
+		return &FrontMatterError{Format: fm.Format, Err: err}
+	}
+	fm.Data = data
+	return nil
+}
+
+// trimLine trims trailing white spaces and the line ending of line.
+func trimLine(line []rune) string {
+	return strings.TrimRight(string(line), " \t\r\n")
+}
@@ -0,0 +1,37 @@
+// Package frontmatter decodes YAML and TOML front matter for taomd,
+// which itself depends on no YAML or TOML decoders.
+package frontmatter
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/movsb/taomd"
+	"gopkg.in/yaml.v3"
+)
+
+// WithYAML decodes YAML front matter by gopkg.in/yaml.v3.
+func WithYAML() taomd.Option {
+	return taomd.WithFrontMatterDecoder(taomd.FrontMatterYAML, DecodeYAML)
+}
+
+// WithTOML decodes TOML front matter by github.com/BurntSushi/toml.
+func WithTOML() taomd.Option {
+	return taomd.WithFrontMatterDecoder(taomd.FrontMatterTOML, DecodeTOML)
+}
+
+// DecodeYAML decodes raw as YAML.
+func DecodeYAML(raw string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DecodeTOML decodes raw as TOML.
+func DecodeTOML(raw string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if _, err := toml.Decode(raw, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
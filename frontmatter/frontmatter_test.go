@@ -0,0 +1,43 @@
+package frontmatter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/movsb/taomd"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		markdown string
+		data     map[string]interface{}
+	}{
+		{"---\ntitle: a\ntags: [b, c]\n---\n", map[string]interface{}{"title": "a", "tags": []interface{}{"b", "c"}}},
+		{"+++\ntitle = 'a'\ntags = ['b', 'c']\n+++\n", map[string]interface{}{"title": "a", "tags": []interface{}{"b", "c"}}},
+	}
+	for _, test := range tests {
+		doc, err := taomd.ParseReader(strings.NewReader(test.markdown),
+			taomd.WithExtensions(taomd.ExtFrontMatter), WithYAML(), WithTOML())
+		if err != nil {
+			t.Errorf("%q: %v", test.markdown, err)
+			continue
+		}
+		if data := doc.FrontMatter().Data; !reflect.DeepEqual(data, test.data) {
+			t.Errorf("%q: want %v, given %v", test.markdown, test.data, data)
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	for _, markdown := range []string{"---\na: [\n---\n", "+++\na = \n+++\n"} {
+		doc, err := taomd.ParseReader(strings.NewReader(markdown),
+			taomd.WithExtensions(taomd.ExtFrontMatter), WithYAML(), WithTOML())
+		if _, ok := err.(*taomd.FrontMatterError); !ok {
+			t.Errorf("%q: error: %v", markdown, err)
+		}
+		if fm := doc.FrontMatter(); fm == nil || fm.Data != nil {
+			t.Errorf("%q: want the raw front matter, given %#v", markdown, fm)
+		}
+	}
+}
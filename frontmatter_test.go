@@ -0,0 +1,111 @@
+package taomd
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFrontMatter(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "---\ntitle: a\n---\n# foo\n",
+			HTML:     "<h1>foo</h1>\n",
+			Section:  "YAML",
+		},
+		{
+			Markdown: "+++\ntitle = 'a'\n+++\n# foo\n",
+			HTML:     "<h1>foo</h1>\n",
+			Section:  "TOML",
+		},
+		{
+			Markdown: "{\n\"title\": \"a\"\n}\n# foo\n",
+			HTML:     "<h1>foo</h1>\n",
+			Section:  "JSON",
+		},
+		{
+			Markdown: "{\"title\": \"a\"}\n# foo\n",
+			HTML:     "<h1>foo</h1>\n",
+			Section:  "JSON",
+		},
+		{
+			Markdown: "{.lead}\nIntro\n",
+			HTML:     "<p>{.lead}\nIntro</p>\n",
+			Section:  "Not front matter",
+		},
+		{
+			Markdown: "{ \"title\": \"a\",\n}\n",
+			HTML:     "<p>{ &quot;title&quot;: &quot;a&quot;,\n}</p>\n",
+			Section:  "Not front matter",
+		},
+		{
+			Markdown: "---\ntitle: a\n",
+			HTML:     "<hr />\n<p>title: a</p>\n",
+			Section:  "Not front matter",
+		},
+		{
+			Markdown: "\n---\ntitle: a\n---\n",
+			HTML:     "<hr />\n<h2>title: a</h2>\n",
+			Section:  "Not front matter",
+		},
+	}, WithExtensions(ExtFrontMatter))
+}
+
+func TestFrontMatterData(t *testing.T) {
+	tests := []struct {
+		markdown string
+		format   FrontMatterFormat
+		raw      string
+		data     map[string]interface{}
+	}{
+		{"{\n\"a\": 1\n}\nfoo\n", FrontMatterJSON, "{\n\"a\": 1\n}\n", map[string]interface{}{"a": 1.0}},
+		{"{\"a\": 1}\nfoo\n", FrontMatterJSON, "{\"a\": 1}\n", map[string]interface{}{"a": 1.0}},
+		// No decoders for YAML and TOML by default.
+		{"---\na: 1\n---\nfoo\n", FrontMatterYAML, "a: 1\n", nil},
+		{"+++\na = 1\n+++\nfoo\n", FrontMatterTOML, "a = 1\n", nil},
+	}
+	for _, test := range tests {
+		doc, err := ParseReader(strings.NewReader(test.markdown), WithExtensions(ExtFrontMatter))
+		if err != nil {
+			t.Errorf("%q: %v", test.markdown, err)
+			continue
+		}
+		fm := doc.FrontMatter()
+		if fm == nil {
+			t.Errorf("%q: no front matter", test.markdown)
+			continue
+		}
+		if fm.Format != test.format || fm.Raw != test.raw || !reflect.DeepEqual(fm.Data, test.data) {
+			t.Errorf("%q: want %v %q %v, given %v %q %v", test.markdown,
+				test.format, test.raw, test.data, fm.Format, fm.Raw, fm.Data)
+		}
+	}
+}
+
+func TestFrontMatterError(t *testing.T) {
+	failed := errors.New("failed")
+	decode := func(raw string) (map[string]interface{}, error) {
+		return nil, failed
+	}
+
+	doc, err := ParseReader(strings.NewReader("---\na: [\n---\n# foo\n"),
+		WithExtensions(ExtFrontMatter), WithFrontMatterDecoder(FrontMatterYAML, decode))
+	if e, ok := err.(*FrontMatterError); !ok || e.Format != FrontMatterYAML || e.Err != failed {
+		t.Fatalf("error: %v", err)
+	}
+	if fm := doc.FrontMatter(); fm == nil || fm.Raw != "a: [\n" || fm.Data != nil {
+		t.Errorf("want the raw front matter, given %#v", fm)
+	}
+	if html, want := Render(doc), "<h1>foo</h1>\n"; html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+	if md, want := RenderMarkdown(doc), "---\na: [\n---\n\n# foo\n"; md != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, md)
+	}
+
+	_, err = ParseReader(strings.NewReader("{\n\"a\": \n}\n"), WithExtensions(ExtFrontMatter))
+	if e, ok := err.(*FrontMatterError); !ok || e.Format != FrontMatterJSON {
+		t.Errorf("error: %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package taomd
+
+import (
+	"testing"
+)
+
+// Examples of the GFM spec, https://github.github.com/gfm/, for the extensions.
+//
+// The HTML of task list items is as it is rendered, rather than as in the spec,
+// whose tests compare HTML with normalized attributes and void elements.
+var gfmExamples = []*Example{
+	{
+		Markdown: "| foo | bar |\n| --- | --- |\n| baz | bim |\n",
+		HTML:     "<table>\n<thead>\n<tr>\n<th>foo</th>\n<th>bar</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>baz</td>\n<td>bim</td>\n</tr>\n</tbody>\n</table>\n",
+		Example:  198,
+		Section:  "Tables (extension)",
+	},
+	{
+		Markdown: "| abc | defghi |\n:-: | -----------:\nbar | baz\n",
+		HTML:     "<table>\n<thead>\n<tr>\n<th align=\"center\">abc</th>\n<th align=\"right\">defghi</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"center\">bar</td>\n<td align=\"right\">baz</td>\n</tr>\n</tbody>\n</table>\n",
+		Example:  199,
+		Section:  "Tables (extension)",
+	},
+	{
+		Markdown: "| f\\|oo  |\n| ------ |\n| b `\\|` az |\n| b **\\|** im |\n",
+		HTML:     "<table>\n<thead>\n<tr>\n<th>f|oo</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>b <code>|</code> az</td>\n</tr>\n<tr>\n<td>b <strong>|</strong> im</td>\n</tr>\n</tbody>\n</table>\n",
+		Example:  200,
+		Section:  "Tables (extension)",
+	},
+	{
+		Markdown: "| abc | def |\n| --- | --- |\n| bar | baz |\n> bar\n",
+		HTML:     "<table>\n<thead>\n<tr>\n<th>abc</th>\n<th>def</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>bar</td>\n<td>baz</td>\n</tr>\n</tbody>\n</table>\n<blockquote>\n<p>bar</p>\n</blockquote>\n",
+		Example:  201,
+		Section:  "Tables (extension)",
+	},
+	{
+		Markdown: "| abc | def |\n| --- | --- |\n| bar | baz |\nbar\n\nbar\n",
+		HTML:     "<table>\n<thead>\n<tr>\n<th>abc</th>\n<th>def</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>bar</td>\n<td>baz</td>\n</tr>\n<tr>\n<td>bar</td>\n<td></td>\n</tr>\n</tbody>\n</table>\n<p>bar</p>\n",
+		Example:  202,
+		Section:  "Tables (extension)",
+	},
+	{
+		Markdown: "| abc | def |\n| --- |\n| bar |\n",
+		HTML:     "<p>| abc | def |\n| --- |\n| bar |</p>\n",
+		Example:  203,
+		Section:  "Tables (extension)",
+	},
+	{
+		Markdown: "| abc | def |\n| --- | --- |\n| bar |\n| bar | baz | boo |\n",
+		HTML:     "<table>\n<thead>\n<tr>\n<th>abc</th>\n<th>def</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>bar</td>\n<td></td>\n</tr>\n<tr>\n<td>bar</td>\n<td>baz</td>\n</tr>\n</tbody>\n</table>\n",
+		Example:  204,
+		Section:  "Tables (extension)",
+	},
+	{
+		Markdown: "| abc | def |\n| --- | --- |\n",
+		HTML:     "<table>\n<thead>\n<tr>\n<th>abc</th>\n<th>def</th>\n</tr>\n</thead>\n</table>\n",
+		Example:  205,
+		Section:  "Tables (extension)",
+	},
+	{
+		Markdown: "- [ ] foo\n- [x] bar\n",
+		HTML:     "<ul>\n<li><input type=\"checkbox\" disabled=\"\" /> foo</li>\n<li><input type=\"checkbox\" checked=\"\" disabled=\"\" /> bar</li>\n</ul>\n",
+		Example:  279,
+		Section:  "Task list items (extension)",
+	},
+	{
+		Markdown: "- [x] foo\n  - [ ] bar\n  - [x] baz\n- [ ] bim\n",
+		HTML:     "<ul>\n<li><input type=\"checkbox\" checked=\"\" disabled=\"\" /> foo\n<ul>\n<li><input type=\"checkbox\" disabled=\"\" /> bar</li>\n<li><input type=\"checkbox\" checked=\"\" disabled=\"\" /> baz</li>\n</ul>\n</li>\n<li><input type=\"checkbox\" disabled=\"\" /> bim</li>\n</ul>\n",
+		Example:  280,
+		Section:  "Task list items (extension)",
+	},
+	{
+		Markdown: "~~Hi~~ Hello, world!\n",
+		HTML:     "<p><del>Hi</del> Hello, world!</p>\n",
+		Example:  491,
+		Section:  "Strikethrough (extension)",
+	},
+	{
+		Markdown: "This ~~has a\n\nnew paragraph~~.\n",
+		HTML:     "<p>This ~~has a</p>\n<p>new paragraph~~.</p>\n",
+		Example:  492,
+		Section:  "Strikethrough (extension)",
+	},
+	{
+		Markdown: "www.commonmark.org\n",
+		HTML:     "<p><a href=\"http://www.commonmark.org\">www.commonmark.org</a></p>\n",
+		Example:  621,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "Visit www.commonmark.org/help for more information.\n",
+		HTML:     "<p>Visit <a href=\"http://www.commonmark.org/help\">www.commonmark.org/help</a> for more information.</p>\n",
+		Example:  622,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "Visit www.commonmark.org.\n\nVisit www.commonmark.org/a.b.\n",
+		HTML:     "<p>Visit <a href=\"http://www.commonmark.org\">www.commonmark.org</a>.</p>\n<p>Visit <a href=\"http://www.commonmark.org/a.b\">www.commonmark.org/a.b</a>.</p>\n",
+		Example:  623,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "www.google.com/search?q=Markup+(business)\n\nwww.google.com/search?q=Markup+(business)))\n\n(www.google.com/search?q=Markup+(business))\n\n(www.google.com/search?q=Markup+(business)\n",
+		HTML: "<p><a href=\"http://www.google.com/search?q=Markup+(business)\">www.google.com/search?q=Markup+(business)</a></p>\n" +
+			"<p><a href=\"http://www.google.com/search?q=Markup+(business)\">www.google.com/search?q=Markup+(business)</a>))</p>\n" +
+			"<p>(<a href=\"http://www.google.com/search?q=Markup+(business)\">www.google.com/search?q=Markup+(business)</a>)</p>\n" +
+			"<p>(<a href=\"http://www.google.com/search?q=Markup+(business)\">www.google.com/search?q=Markup+(business)</a></p>\n",
+		Example: 624,
+		Section: "Autolinks (extension)",
+	},
+	{
+		Markdown: "www.google.com/search?q=(business))+ok\n",
+		HTML:     "<p><a href=\"http://www.google.com/search?q=(business))+ok\">www.google.com/search?q=(business))+ok</a></p>\n",
+		Example:  625,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "www.google.com/search?q=commonmark&hl=en\n\nwww.google.com/search?q=commonmark&hl;\n",
+		HTML:     "<p><a href=\"http://www.google.com/search?q=commonmark&amp;hl=en\">www.google.com/search?q=commonmark&amp;hl=en</a></p>\n<p><a href=\"http://www.google.com/search?q=commonmark\">www.google.com/search?q=commonmark</a>&amp;hl;</p>\n",
+		Example:  626,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "www.commonmark.org/he<lp\n",
+		HTML:     "<p><a href=\"http://www.commonmark.org/he\">www.commonmark.org/he</a>&lt;lp</p>\n",
+		Example:  627,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "http://commonmark.org\n\n(Visit https://encrypted.google.com/search?q=Markup+(business))\n\nAnonymous FTP is available at ftp://foo.bar.baz.\n",
+		HTML:     "<p><a href=\"http://commonmark.org\">http://commonmark.org</a></p>\n<p>(Visit <a href=\"https://encrypted.google.com/search?q=Markup+(business)\">https://encrypted.google.com/search?q=Markup+(business)</a>)</p>\n<p>Anonymous FTP is available at <a href=\"ftp://foo.bar.baz\">ftp://foo.bar.baz</a>.</p>\n",
+		Example:  628,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "foo@bar.baz\n",
+		HTML:     "<p><a href=\"mailto:foo@bar.baz\">foo@bar.baz</a></p>\n",
+		Example:  629,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "hello@mail+xyz.example isn't valid, but hello+xyz@mail.example is.\n",
+		HTML:     "<p>hello@mail+xyz.example isn't valid, but <a href=\"mailto:hello+xyz@mail.example\">hello+xyz@mail.example</a> is.</p>\n",
+		Example:  630,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "a.b-c_d@a.b\n\na.b-c_d@a.b.\n\na.b-c_d@a.b-\n\na.b-c_d@a.b_\n",
+		HTML:     "<p><a href=\"mailto:a.b-c_d@a.b\">a.b-c_d@a.b</a></p>\n<p><a href=\"mailto:a.b-c_d@a.b\">a.b-c_d@a.b</a>.</p>\n<p>a.b-c_d@a.b-</p>\n<p>a.b-c_d@a.b_</p>\n",
+		Example:  631,
+		Section:  "Autolinks (extension)",
+	},
+	{
+		Markdown: "<strong> <title> <style> <em>\n\n<blockquote>\n  <xmp> is disallowed.  <XMP> is also disallowed.\n</blockquote>\n",
+		HTML:     "<p><strong> &lt;title> &lt;style> <em></p>\n<blockquote>\n  &lt;xmp> is disallowed.  &lt;XMP> is also disallowed.\n</blockquote>\n",
+		Example:  653,
+		Section:  "Disallowed Raw HTML (extension)",
+	},
+}
+
+func TestGFM(t *testing.T) {
+	testExamples(t, gfmExamples, WithExtensions(GFM))
+}
+
+func TestGFMRoundTrip(t *testing.T) {
+	testRoundTrip(t, gfmExamples, WithExtensions(GFM))
+}
+
+// TestTagFilterEnd tests disallowed tags at the end of the input.
+func TestTagFilterEnd(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "a\n\n<script",
+			HTML:     "<p>a</p>\n&lt;script",
+			Section:  "Disallowed Raw HTML (extension)",
+		},
+		{
+			Markdown: "<div>\n</textarea",
+			HTML:     "<div>\n&lt;/textarea",
+			Section:  "Disallowed Raw HTML (extension)",
+		},
+	}, WithExtensions(ExtTagFilter))
+}
@@ -1,3 +1,8 @@
 module github.com/movsb/taomd
 
 go 1.12
+
+require (
+	github.com/BurntSushi/toml v0.3.1
+	gopkg.in/yaml.v3 v3.0.1
+)
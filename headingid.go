@@ -0,0 +1,162 @@
+package taomd
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A Slugger makes the id of a heading from its text content.
+// It may return an empty string, for which "heading" is used instead.
+type Slugger func(text string) string
+
+// WithHeadingIDs makes ids for headings with slug, or GitHubSlug if it is nil.
+//
+// A heading can also be given an explicit id by ending it with "{#id}",
+// as in "## Installation {#install}". Repeated ids are made unique by
+// appending "-1", "-2" and so on, but explicit ids are kept as they are.
+func WithHeadingIDs(slug Slugger) Option {
+	return func(p *Parser) {
+		if slug == nil {
+			slug = GitHubSlug
+		}
+		p.slugger = slug
+	}
+}
+
+// GitHubSlug makes ids as GitHub does: the text is lowercased,
+// punctuation other than hyphens (-) and underscores (_) is removed,
+// and each space is replaced by a hyphen. Letters and digits in
+// any script are kept.
+func GitHubSlug(text string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			sb.WriteRune('-')
+		case r == '-', r == '_', unicode.IsLetter(r), unicode.IsDigit(r), unicode.IsMark(r):
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// ASCIISlug makes ids of lowercase ASCII letters and digits only,
+// with a single hyphen (-) for each run of other characters in between.
+func ASCIISlug(text string) string {
+	return slugRuns(text, func(r rune) bool {
+		return r < unicode.MaxASCII && isAlNum(r)
+	})
+}
+
+// CJKSlug is like ASCIISlug, but keeps Chinese, Japanese and Korean characters too,
+// so that headings written in them do not end up with empty ids.
+func CJKSlug(text string) string {
+	return slugRuns(text, func(r rune) bool {
+		return r < unicode.MaxASCII && isAlNum(r) ||
+			unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+	})
+}
+
+// slugRuns lowercases text, keeps the runes for which keep returns true,
+// and joins the runs of them with hyphens (-).
+func slugRuns(text string, keep func(r rune) bool) string {
+	var sb strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(text) {
+		if !keep(r) {
+			sep = true
+			continue
+		}
+		if sep && sb.Len() > 0 {
+			sb.WriteByte('-')
+		}
+		sep = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// parseHeadingIDs takes explicit ids, "{#id}", or attribute lists if withAttributes,
+// off the ends of headings, before the texts of the headings are parsed into inlines.
+func (doc *Document) parseHeadingIDs(withAttributes bool) {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		if h, ok := n.(*Heading); ok && entering {
+			if withAttributes {
+				h.parseAttributes()
+			} else {
+				h.parseID()
+			}
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+}
+
+// parseID takes the explicit id off the end of the text of h.
+// The id must not be empty, and must not contain white spaces or braces.
+func (h *Heading) parseID() {
+	text := strings.TrimRight(h.text, " \t\n")
+	if !strings.HasSuffix(text, "}") {
+		return
+	}
+	i := strings.LastIndex(text, "{#")
+	if i < 0 || i > 0 && text[i-1] == '\\' {
+		return
+	}
+	id := text[i+2 : len(text)-1]
+	if id == "" || strings.ContainsAny(id, " \t\n{}") {
+		return
+	}
+	h.ID = id
+	h.explicitID = true
+	h.text = strings.TrimRight(text[:i], " \t") + h.text[len(text):]
+}
+
+// parseAttributes takes the attribute list off the end of the text of h.
+// The id in it, if any, is the explicit id of h.
+func (h *Heading) parseAttributes() {
+	text, attrs := trailingAttributes(h.text)
+	if attrs == nil {
+		return
+	}
+	h.text = text
+	if id := attrs.take("id"); id != "" {
+		h.ID = id
+		h.explicitID = true
+	}
+	h.Attributes.merge(attrs)
+}
+
+// makeHeadingIDs makes ids for headings that have no explicit ones,
+// unique in the document.
+func (doc *Document) makeHeadingIDs(slug Slugger) {
+	var headings []*Heading
+	used := make(map[string]bool)
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		if h, ok := n.(*Heading); ok && entering {
+			headings = append(headings, h)
+			if h.explicitID {
+				used[h.ID] = true
+			}
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+
+	for _, h := range headings {
+		if h.explicitID {
+			continue
+		}
+		id := slug(h.TextContent())
+		if id == "" {
+			id = "heading"
+		}
+		unique := id
+		for n := 1; used[unique]; n++ {
+			unique = id + "-" + strconv.Itoa(n)
+		}
+		used[unique] = true
+		h.ID = unique
+	}
+}
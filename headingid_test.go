@@ -0,0 +1,66 @@
+package taomd
+
+import (
+	"testing"
+)
+
+func TestHeadingIDs(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "# Hello, World!\n## Hello, World!\n",
+			HTML:     "<h1 id=\"hello-world\">Hello, World!</h1>\n<h2 id=\"hello-world-1\">Hello, World!</h2>\n",
+			Section:  "Heading IDs",
+		},
+		{
+			Markdown: "## Install {#install}\n## Install\n",
+			HTML:     "<h2 id=\"install\">Install</h2>\n<h2 id=\"install-1\">Install</h2>\n",
+			Section:  "Heading IDs",
+		},
+		{
+			Markdown: "# *Emph* `code`\n",
+			HTML:     "<h1 id=\"emph-code\"><em>Emph</em> <code>code</code></h1>\n",
+			Section:  "Heading IDs",
+		},
+		{
+			Markdown: "# 你好 世界\n",
+			HTML:     "<h1 id=\"你好-世界\">你好 世界</h1>\n",
+			Section:  "Heading IDs",
+		},
+		{
+			Markdown: "#\n",
+			HTML:     "<h1 id=\"heading\"></h1>\n",
+			Section:  "Heading IDs",
+		},
+	}, WithHeadingIDs(nil))
+}
+
+func TestSlugs(t *testing.T) {
+	tests := []struct {
+		slug Slugger
+		text string
+		want string
+	}{
+		{GitHubSlug, "Hello, World!", "hello-world"},
+		{GitHubSlug, "a_b -- c", "a_b----c"},
+		{GitHubSlug, "Ünïcödé 1", "ünïcödé-1"},
+		{ASCIISlug, "Hello, World!", "hello-world"},
+		{ASCIISlug, "--a  b--", "a-b"},
+		{ASCIISlug, "你好", ""},
+		{CJKSlug, "Go 语言", "go-语言"},
+	}
+	for _, test := range tests {
+		if got := test.slug(test.text); got != test.want {
+			t.Errorf("%q: want %q, given %q", test.text, test.want, got)
+		}
+	}
+}
+
+func TestHeadingIDsSlugger(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "# 你好\n## Go 语言\n",
+			HTML:     "<h1 id=\"heading\">你好</h1>\n<h2 id=\"go\">Go 语言</h2>\n",
+			Section:  "Sluggers",
+		},
+	}, WithHeadingIDs(ASCIISlug))
+}
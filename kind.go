@@ -0,0 +1,128 @@
+package taomd
+
+import (
+	"strconv"
+)
+
+// A Kind tells what kind a node is.
+type Kind int
+
+// Kinds of nodes.
+const (
+	KindDocument Kind = iota
+	KindBlankLine
+	KindHorizontalRule
+	KindParagraph
+	KindHeading
+	KindSetextHeading
+	KindHtmlBlock
+	KindBlockQuote
+	KindList
+	KindListItem
+	KindCodeBlock
+	KindLinkReferenceDefinition
+	KindText
+	KindCodeSpan
+	KindLink
+	KindImage
+	KindEmphasis
+	KindHardLineBreak
+	KindSoftLineBreak
+	KindHtmlTag
+	KindTable
+	KindTableRow
+	KindTableCell
+	KindStrikethrough
+	KindFootnoteDefinition
+	KindFootnoteReference
+	KindTableOfContents
+	KindMathInline
+	KindMathBlock
+	KindEmoji
+	KindDefinitionList
+	KindDefinitionTerm
+	KindDefinitionDescription
+	KindAlert
+	KindFencedDiv
+)
+
+var kindNames = [...]string{
+	KindDocument:                "Document",
+	KindBlankLine:               "BlankLine",
+	KindHorizontalRule:          "HorizontalRule",
+	KindParagraph:               "Paragraph",
+	KindHeading:                 "Heading",
+	KindSetextHeading:           "SetextHeading",
+	KindHtmlBlock:               "HtmlBlock",
+	KindBlockQuote:              "BlockQuote",
+	KindList:                    "List",
+	KindListItem:                "ListItem",
+	KindCodeBlock:               "CodeBlock",
+	KindLinkReferenceDefinition: "LinkReferenceDefinition",
+	KindText:                    "Text",
+	KindCodeSpan:                "CodeSpan",
+	KindLink:                    "Link",
+	KindImage:                   "Image",
+	KindEmphasis:                "Emphasis",
+	KindHardLineBreak:           "HardLineBreak",
+	KindSoftLineBreak:           "SoftLineBreak",
+	KindHtmlTag:                 "HtmlTag",
+	KindTable:                   "Table",
+	KindTableRow:                "TableRow",
+	KindTableCell:               "TableCell",
+	KindStrikethrough:           "Strikethrough",
+	KindFootnoteDefinition:      "FootnoteDefinition",
+	KindFootnoteReference:       "FootnoteReference",
+	KindTableOfContents:         "TableOfContents",
+	KindMathInline:              "MathInline",
+	KindMathBlock:               "MathBlock",
+	KindEmoji:                   "Emoji",
+	KindDefinitionList:          "DefinitionList",
+	KindDefinitionTerm:          "DefinitionTerm",
+	KindDefinitionDescription:   "DefinitionDescription",
+	KindAlert:                   "Alert",
+	KindFencedDiv:               "FencedDiv",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) && kindNames[k] != "" {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+func (doc *Document) Kind() Kind              { return KindDocument }
+func (bl *BlankLine) Kind() Kind              { return KindBlankLine }
+func (hr *HorizontalRule) Kind() Kind         { return KindHorizontalRule }
+func (pp *Paragraph) Kind() Kind              { return KindParagraph }
+func (h *Heading) Kind() Kind                 { return KindHeading }
+func (h *SetextHeading) Kind() Kind           { return KindSetextHeading }
+func (hb *HtmlBlock) Kind() Kind              { return KindHtmlBlock }
+func (bq *BlockQuote) Kind() Kind             { return KindBlockQuote }
+func (l *List) Kind() Kind                    { return KindList }
+func (li *ListItem) Kind() Kind               { return KindListItem }
+func (cb *CodeBlock) Kind() Kind              { return KindCodeBlock }
+func (l *LinkReferenceDefinition) Kind() Kind { return KindLinkReferenceDefinition }
+func (t *Text) Kind() Kind                    { return KindText }
+func (cs *CodeSpan) Kind() Kind               { return KindCodeSpan }
+func (l *Link) Kind() Kind                    { return KindLink }
+func (i *Image) Kind() Kind                   { return KindImage }
+func (e *Emphasis) Kind() Kind                { return KindEmphasis }
+func (b *HardLineBreak) Kind() Kind           { return KindHardLineBreak }
+func (b *SoftLineBreak) Kind() Kind           { return KindSoftLineBreak }
+func (t *HtmlTag) Kind() Kind                 { return KindHtmlTag }
+func (t *Table) Kind() Kind                   { return KindTable }
+func (r *TableRow) Kind() Kind                { return KindTableRow }
+func (c *TableCell) Kind() Kind               { return KindTableCell }
+func (st *Strikethrough) Kind() Kind          { return KindStrikethrough }
+func (fd *FootnoteDefinition) Kind() Kind     { return KindFootnoteDefinition }
+func (fr *FootnoteReference) Kind() Kind      { return KindFootnoteReference }
+func (t *TableOfContents) Kind() Kind         { return KindTableOfContents }
+func (m *MathInline) Kind() Kind              { return KindMathInline }
+func (mb *MathBlock) Kind() Kind              { return KindMathBlock }
+func (e *Emoji) Kind() Kind                   { return KindEmoji }
+func (dl *DefinitionList) Kind() Kind         { return KindDefinitionList }
+func (dt *DefinitionTerm) Kind() Kind         { return KindDefinitionTerm }
+func (dd *DefinitionDescription) Kind() Kind  { return KindDefinitionDescription }
+func (a *Alert) Kind() Kind                   { return KindAlert }
+func (d *FencedDiv) Kind() Kind               { return KindFencedDiv }
@@ -0,0 +1,900 @@
+package taomd
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// RenderMarkdown renders doc back to normalized markdown.
+//
+// Errors are discarded and what has been rendered so far is returned.
+// Use RenderMarkdownTo to see them.
+func RenderMarkdown(doc *Document) string {
+	var sb strings.Builder
+	RenderMarkdownTo(&sb, doc)
+	return sb.String()
+}
+
+// RenderMarkdownTo renders doc back to normalized markdown into w.
+//
+// The syntax recorded in the document, such as heading styles, code fences,
+// list markers, emphasis delimiters and link styles, is kept, so that parsing
+// the output gives an equivalent document. Everything else is normalized:
+// blocks are separated by a blank line, leading spaces are removed, and
+// link reference definitions are moved to the end of the document.
+// Front matter, if any, is written as it is.
+//
+// It returns an *InternalError if doc contains nodes it cannot render,
+// in which case the blocks before them are still written, or the error
+// returned by w.
+func RenderMarkdownTo(w io.Writer, doc *Document) (err error) {
+	defer recoverError(&err)
+
+	lines, err := markdownBlocks(doc.blocks, false, doc.extensions)
+
+	if len(doc.definitions) > 0 && err == nil {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		for _, def := range doc.definitions {
+			lines = append(lines, markdownDefinition(def)...)
+		}
+	}
+
+	if fm := doc.frontMatter; fm != nil {
+		fmLines := markdownFrontMatter(fm)
+		if len(lines) > 0 {
+			fmLines = append(fmLines, "")
+		}
+		lines = append(fmLines, lines...)
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, line := range lines {
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// markdownBlocks renders blocks as lines, separated by blank lines unless tight.
+// On an error, the lines of the blocks before are returned with it.
+func markdownBlocks(blocks []Blocker, tight bool, exts Extension) ([]string, error) {
+	var lines []string
+	var prev Blocker
+
+	for _, block := range blocks {
+		switch typed := block.(type) {
+		case *BlankLine:
+			continue
+		case *Paragraph:
+			// HACK: contents are parsed as link reference definitions.
+			if len(typed.Inlines) == 0 {
+				continue
+			}
+		}
+
+		blockLines, err := markdownBlock(block, prev, tight, exts)
+		if err != nil {
+			return lines, err
+		}
+		switch typed := block.(type) {
+		case *Paragraph, *HorizontalRule, *BlockQuote, *Alert, *List, *Table, *DefinitionList:
+			// Headings and code blocks have their attribute lists in them.
+			if attrs := markdownAttributes(*typed.(attributer).attributes()); attrs != "" {
+				blockLines = append([]string{attrs}, blockLines...)
+			}
+		}
+		// Some HTML blocks only end at a blank line.
+		if !tight || endsAtBlankLine(prev) {
+			lines = separate(lines)
+		}
+		lines = append(lines, blockLines...)
+		prev = block
+	}
+
+	return lines, nil
+}
+
+// markdownBlock renders block as lines. prev is the block before it, if any.
+func markdownBlock(block Blocker, prev Blocker, tight bool, exts Extension) ([]string, error) {
+	switch typed := block.(type) {
+	default:
+		return nil, internalErrorf("unhandled block: %s", block.Kind())
+	case *Paragraph:
+		s, err := markdownInlines(typed.Inlines, true, exts)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Split(s, "\n"), nil
+	case *Heading:
+		return markdownHeading(typed, exts)
+	case *HorizontalRule:
+		marker := string(typed.Marker)
+		// The line right after a paragraph would be a setext heading underline.
+		if _, ok := prev.(*Paragraph); ok && tight && typed.Marker == '-' {
+			return []string{"- - -"}, nil
+		}
+		return []string{strings.Repeat(marker, 3)}, nil
+	case *CodeBlock:
+		// An indented code block right after a list would be part of the list.
+		_, afterList := prev.(*List)
+		return markdownCodeBlock(typed, afterList), nil
+	case *HtmlBlock:
+		var sb strings.Builder
+		for _, line := range typed.Lines {
+			sb.WriteString(string(line))
+		}
+		return strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n"), nil
+	case *BlockQuote:
+		lines, err := markdownBlocks(typed.blocks, false, exts)
+		if err != nil {
+			return nil, err
+		}
+		return markdownBlockQuote(lines), nil
+	case *Alert:
+		lines, err := markdownBlocks(typed.blocks, false, exts)
+		if err != nil {
+			return nil, err
+		}
+		// A paragraph right after the kind is written as the lines after it,
+		// and other blocks are separated from it, as they may not interrupt it.
+		if len(lines) > 0 && !startsWithParagraph(typed.blocks) {
+			lines = append([]string{""}, lines...)
+		}
+		kind := "[!" + strings.ToUpper(typed.Type) + "]"
+		return markdownBlockQuote(append([]string{kind}, lines...)), nil
+	case *FencedDiv:
+		return markdownFencedDiv(typed, exts)
+	case *List:
+		return markdownList(typed, exts)
+	case *DefinitionList:
+		return markdownDefinitionList(typed, exts)
+	case *Table:
+		return markdownTable(typed, exts)
+	case *LinkReferenceDefinition:
+		return markdownDefinition(typed), nil
+	case *FootnoteDefinition:
+		return markdownFootnote(typed, exts)
+	case *TableOfContents:
+		return []string{typed.Marker}, nil
+	case *MathBlock:
+		return markdownMathBlock(typed), nil
+	}
+}
+
+// separate appends a blank line to lines, unless they are empty or end with one,
+// as an HTML block that ends only at the end of its container may.
+func separate(lines []string) []string {
+	if len(lines) == 0 || lines[len(lines)-1] == "" {
+		return lines
+	}
+	return append(lines, "")
+}
+
+// endsAtBlankLine tells whether block is an HTML block that ends at a blank line.
+func endsAtBlankLine(block Blocker) bool {
+	hb, ok := block.(*HtmlBlock)
+	return ok && (hb.condition == 6 || hb.condition == 7)
+}
+
+// startsWithParagraph tells whether the first block rendered of blocks is a paragraph.
+func startsWithParagraph(blocks []Blocker) bool {
+	for _, block := range blocks {
+		switch typed := block.(type) {
+		case *BlankLine:
+			continue
+		case *Paragraph:
+			if len(typed.Inlines) == 0 {
+				continue
+			}
+			return len(typed.Attributes) == 0
+		}
+		return false
+	}
+	return false
+}
+
+// markdownBlockQuote prefixes lines with block quote markers.
+func markdownBlockQuote(lines []string) []string {
+	if len(lines) == 0 {
+		return []string{">"}
+	}
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return lines
+}
+
+func markdownHeading(h *Heading, exts Extension) ([]string, error) {
+	s, err := markdownInlines(h.Inlines, h.Setext, exts)
+	if err != nil {
+		return nil, err
+	}
+	attrs := h.Attributes
+	if h.explicitID {
+		attrs = append(Attributes{{Key: "id", Value: h.ID}}, attrs...)
+	}
+	if attrs := markdownAttributes(attrs); attrs != "" {
+		s += " " + attrs
+	} else if i := strings.LastIndex(s, "{"); i >= 0 && strings.HasSuffix(s, "}") {
+		// It would be taken as an explicit id or an attribute list.
+		s = s[:i] + `\` + s[i:]
+	}
+
+	multiline := strings.Contains(s, "\n")
+	if s != "" && h.Level <= 2 && (h.Setext || multiline) {
+		underline := "==="
+		if h.Level == 2 {
+			underline = "---"
+		}
+		return append(strings.Split(s, "\n"), underline), nil
+	}
+
+	marker := strings.Repeat("#", h.Level)
+	if s == "" {
+		return []string{marker}, nil
+	}
+
+	// The optional closing sequence of #s would eat it.
+	if strings.HasSuffix(s, "#") {
+		s = s[:len(s)-1] + `\#`
+	}
+	return []string{marker + " " + strings.Replace(s, "\n", " ", -1)}, nil
+}
+
+// markdownMathBlock renders mb as a code block fenced by "$$",
+// or by "```" with the info string "math" if it has attributes.
+func markdownMathBlock(mb *MathBlock) []string {
+	cb := &CodeBlock{
+		Attributes:  mb.Attributes,
+		FenceMarker: mb.FenceMarker,
+		FenceLength: mb.FenceLength,
+		lines:       mb.lines,
+	}
+	if cb.FenceMarker != '$' || len(cb.Attributes) > 0 {
+		cb.Info = "math"
+		if cb.FenceMarker == '$' {
+			cb.FenceMarker, cb.FenceLength = '`', 3
+		}
+	}
+	return markdownCodeBlock(cb, false)
+}
+
+func markdownCodeBlock(cb *CodeBlock, afterList bool) []string {
+	var content []string
+	for _, line := range cb.Lines() {
+		content = append(content, strings.TrimSuffix(line, "\n"))
+	}
+
+	if !cb.isFenced() && !afterList && len(content) > 0 && len(cb.Attributes) == 0 {
+		lines := make([]string, len(content))
+		for i, line := range content {
+			if line != "" {
+				lines[i] = "    " + line
+			}
+		}
+		return lines
+	}
+
+	marker, length := cb.FenceMarker, cb.FenceLength
+	if !cb.isFenced() {
+		marker, length = '`', 3
+	}
+
+	// The fence must be longer than any line of the content that would close it.
+	for _, content := range content {
+		_, line := skipPrefixSpaces([]rune(content), 3)
+		n := 0
+		for n < len(line) && line[n] == marker {
+			n++
+		}
+		if n >= length && isBlankLine(line[n:]) {
+			length = n + 1
+		}
+	}
+
+	fence := strings.Repeat(string(marker), length)
+	info := strings.TrimSpace(cb.Info + " " + markdownAttributes(cb.Attributes))
+	// An info string starting with the fence character would lengthen the fence.
+	if strings.HasPrefix(info, string(marker)) {
+		info = " " + info
+	}
+	lines := []string{fence + escapeMarkdown(info, `\&`)}
+	lines = append(lines, content...)
+	lines = append(lines, fence)
+	return lines
+}
+
+func markdownList(l *List, exts Extension) ([]string, error) {
+	var lines []string
+	n := 0
+
+	for _, item := range l.Items {
+		if _, ok := item.(*BlankLine); ok {
+			continue
+		}
+		li, ok := item.(*ListItem)
+		if !ok {
+			return nil, internalErrorf("unhandled list item: %s", item.Kind())
+		}
+
+		marker := string(l.MarkerChar)
+		if l.Ordered {
+			marker = strconv.Itoa(l.Start+n) + marker
+		}
+		n++
+
+		itemLines, err := markdownBlocks(li.blocks, l.Tight, exts)
+		if err != nil {
+			return nil, err
+		}
+
+		var task string
+		switch li.Task {
+		case TaskUnchecked:
+			task = "[ ]"
+		case TaskChecked:
+			task = "[x]"
+		}
+		if task != "" {
+			if pp, ok := li.blocks[0].(*Paragraph); ok && len(pp.Inlines) > 0 {
+				itemLines[0] = task + " " + itemLines[0]
+			} else {
+				itemLines = append([]string{task}, itemLines...)
+			}
+		}
+
+		if !l.Tight {
+			lines = separate(lines)
+		}
+
+		if len(itemLines) == 0 {
+			lines = append(lines, marker)
+			continue
+		}
+
+		// A first line that starts with spaces, as an HTML block may,
+		// is written after an empty marker line.
+		if strings.HasPrefix(itemLines[0], " ") {
+			itemLines = append([]string{""}, itemLines...)
+		}
+
+		indent := strings.Repeat(" ", len(marker)+1)
+		for i, line := range itemLines {
+			switch {
+			case i == 0 && line == "":
+				line = marker
+			case i == 0:
+				line = marker + " " + line
+			case line != "":
+				line = indent + line
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// markdownFencedDiv renders d with its attribute list in its opening fence.
+func markdownFencedDiv(d *FencedDiv, exts Extension) ([]string, error) {
+	lines, err := markdownBlocks(d.blocks, false, exts)
+	if err != nil {
+		return nil, err
+	}
+	fence := strings.Repeat(":", d.FenceLength)
+	info := strings.TrimSpace(d.Info + " " + markdownAttributes(d.Attributes))
+	lines = append([]string{fence + " " + info}, lines...)
+	return append(lines, fence), nil
+}
+
+// markdownDefinitionList renders dl with a blank line before each term after
+// a description, and, if dl is loose, before each description too.
+// The content of a description is indented, except the first line,
+// which follows the colon unless it is an indented code block.
+func markdownDefinitionList(dl *DefinitionList, exts Extension) ([]string, error) {
+	var lines []string
+	var prev Blocker
+
+	for _, item := range dl.Items {
+		switch typed := item.(type) {
+		case *BlankLine:
+			continue
+		case *DefinitionTerm:
+			s, err := markdownInlines(typed.Inlines, true, exts)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := prev.(*DefinitionDescription); ok {
+				lines = separate(lines)
+			}
+			lines = append(lines, strings.Replace(s, "\n", " ", -1))
+		case *DefinitionDescription:
+			ddLines, err := markdownBlocks(typed.blocks, dl.Tight, exts)
+			if err != nil {
+				return nil, err
+			}
+			if !dl.Tight {
+				lines = separate(lines)
+			}
+			if len(ddLines) > 0 && !strings.HasPrefix(ddLines[0], " ") {
+				ddLines[0] = ": " + ddLines[0]
+			} else {
+				ddLines = append([]string{":"}, ddLines...)
+			}
+			for i := 1; i < len(ddLines); i++ {
+				if ddLines[i] != "" {
+					ddLines[i] = strings.Repeat(" ", descriptionIndent) + ddLines[i]
+				}
+			}
+			lines = append(lines, ddLines...)
+		default:
+			return nil, internalErrorf("unhandled definition list item: %s", item.Kind())
+		}
+		prev = item
+	}
+
+	return lines, nil
+}
+
+// markdownAttributes renders attrs as an attribute list, or an empty string
+// if there are none. Attributes with names that are not valid are left out.
+func markdownAttributes(attrs Attributes) string {
+	var parts []string
+	for _, attr := range attrs {
+		switch {
+		case attr.Key == "id":
+			parts = append(parts, "#"+attr.Value)
+		case attr.Key == "class":
+			for _, class := range strings.Fields(attr.Value) {
+				parts = append(parts, "."+class)
+			}
+		case isAttributeName(attr.Key):
+			parts = append(parts, attr.Key+"="+markdownAttributeValue(attr.Value))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+// markdownAttributeValue quotes value if it cannot be written as it is.
+func markdownAttributeValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\"'=<>`{}") {
+		return value
+	}
+	if strings.Contains(value, `"`) {
+		return "'" + value + "'"
+	}
+	return `"` + value + `"`
+}
+
+// markdownFrontMatter renders fm as it is, with its delimiters.
+func markdownFrontMatter(fm *FrontMatter) []string {
+	lines := strings.Split(strings.TrimSuffix(fm.Raw, "\n"), "\n")
+	switch fm.Format {
+	case FrontMatterYAML:
+		lines = append(append([]string{"---"}, lines...), "---")
+	case FrontMatterTOML:
+		lines = append(append([]string{"+++"}, lines...), "+++")
+	}
+	return lines
+}
+
+// markdownFootnote renders fd with its content indented, except the first line,
+// which follows the label unless it is an indented code block.
+func markdownFootnote(fd *FootnoteDefinition, exts Extension) ([]string, error) {
+	lines, err := markdownBlocks(fd.blocks, false, exts)
+	if err != nil {
+		return nil, err
+	}
+	label := "[^" + fd.Label + "]:"
+	if len(lines) > 0 && !strings.HasPrefix(lines[0], " ") {
+		lines[0] = label + " " + lines[0]
+	} else {
+		lines = append([]string{label}, lines...)
+	}
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = strings.Repeat(" ", footnoteIndent) + lines[i]
+		}
+	}
+	return lines, nil
+}
+
+func markdownTable(t *Table, exts Extension) ([]string, error) {
+	row := func(r *TableRow) (string, error) {
+		var sb strings.Builder
+		sb.WriteString("|")
+		for _, cell := range r.Cells {
+			w := &markdownInlineWriter{inTable: true, exts: exts}
+			if err := w.inlines(cell.Inlines); err != nil {
+				return "", err
+			}
+			sb.WriteString(" " + w.sb.String() + " |")
+		}
+		return sb.String(), nil
+	}
+
+	header, err := row(t.Header)
+	if err != nil {
+		return nil, err
+	}
+	delimiter := "|"
+	for _, align := range t.Aligns {
+		switch align {
+		default:
+			delimiter += " --- |"
+		case AlignLeft:
+			delimiter += " :-- |"
+		case AlignCenter:
+			delimiter += " :-: |"
+		case AlignRight:
+			delimiter += " --: |"
+		}
+	}
+	lines := []string{header, delimiter}
+	for _, r := range t.Rows {
+		line, err := row(r)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func markdownDefinition(def *LinkReferenceDefinition) []string {
+	label := strings.TrimSuffix(strings.TrimPrefix(def.Label, "["), "]")
+	s := "[" + escapeMarkdown(label, `\[]`) + "]: " + markdownDestination(def.Destination)
+	if def.Title != "" {
+		s += " " + markdownTitle(def.Title)
+	}
+	return strings.Split(s, "\n")
+}
+
+// escapeMarkdown escapes the characters in chars of s by backslashes.
+// '&' is only escaped if it could start an entity.
+func escapeMarkdown(s string, chars string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		if strings.ContainsRune(chars, r) {
+			if r != '&' || i+1 < len(s) && (s[i+1] == '#' || isAlNum(rune(s[i+1]))) {
+				sb.WriteByte('\\')
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func markdownDestination(dest string) string {
+	if dest == "" || strings.IndexFunc(dest, func(r rune) bool { return r <= ' ' }) != -1 {
+		return "<" + escapeMarkdown(dest, `\<>&`) + ">"
+	}
+	return escapeMarkdown(dest, `\<>()&`)
+}
+
+func markdownTitle(title string) string {
+	return `"` + escapeMarkdown(title, `\"&`) + `"`
+}
+
+// markdownInlines renders inlines as a string, with lines separated by "\n".
+// atLineStart tells whether the first line starts a line of a block.
+func markdownInlines(inlines []Inline, atLineStart bool, exts Extension) (string, error) {
+	w := &markdownInlineWriter{
+		lineStart: atLineStart,
+		exts:      exts,
+	}
+	if err := w.inlines(inlines); err != nil {
+		return "", err
+	}
+	return w.sb.String(), nil
+}
+
+type markdownInlineWriter struct {
+	sb strings.Builder
+
+	// Is the next character at the start of a line?
+	lineStart bool
+
+	// Is the last inline a shortcut reference link?
+	afterShortcut bool
+
+	// Is the last inline a link or an image?
+	afterLink bool
+
+	// Pipes are escaped in table cells, even inside code spans.
+	inTable bool
+
+	// The extensions the document is parsed with, which decide
+	// what else is markup to be escaped.
+	exts Extension
+}
+
+// write writes markup as is.
+func (w *markdownInlineWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if w.inTable {
+		s = strings.Replace(s, "|", `\|`, -1)
+	}
+	w.sb.WriteString(s)
+	w.lineStart = s[len(s)-1] == '\n'
+	w.afterShortcut = false
+	w.afterLink = false
+}
+
+func (w *markdownInlineWriter) inlines(inlines []Inline) error {
+	for i := 0; i < len(inlines); i++ {
+		// Adjacent texts are escaped as a whole, for a line start
+		// can be split across them, as in "1&#46; foo".
+		if _, ok := inlines[i].(*Text); ok {
+			var text string
+			for ; i < len(inlines); i++ {
+				t, ok := inlines[i].(*Text)
+				if !ok {
+					break
+				}
+				text += t.Text
+			}
+			i--
+			// An exclamation mark right before a link would make it an image.
+			if link, ok := nextInline(inlines, i).(*Link); ok && strings.HasSuffix(text, "!") &&
+				link.Style != Autolink && link.Style != ExtendedAutolink {
+				w.text(text[:len(text)-1])
+				w.write(`\!`)
+				continue
+			}
+			w.text(text)
+			continue
+		}
+		if err := w.inline(inlines[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextInline returns the inline after inlines[i], or nil if there is none.
+func nextInline(inlines []Inline, i int) Inline {
+	if i+1 < len(inlines) {
+		return inlines[i+1]
+	}
+	return nil
+}
+
+func (w *markdownInlineWriter) inline(inline Inline) error {
+	switch it := inline.(type) {
+	default:
+		return internalErrorf("unhandled inline: %s", inline.Kind())
+	case *Text:
+		w.text(it.Text)
+	case *SoftLineBreak:
+		w.write("\n")
+	case *HardLineBreak:
+		w.write("\\\n")
+	case *CodeSpan:
+		w.codeSpan(it.TextContent())
+	case *MathInline:
+		delimiter := "$"
+		if it.Display {
+			delimiter = "$$"
+		}
+		w.write(delimiter + it.Literal + delimiter)
+	case *Emoji:
+		w.write(":" + it.Shortcode + ":")
+	case *HtmlTag:
+		// A tag that would start an HTML block at the start of a line
+		// is indented, so that the line is a paragraph continuation line.
+		if w.lineStart && w.sb.Len() > 0 && startsHtmlBlock(it.Tag) {
+			w.write("    ")
+		}
+		w.write(it.Tag)
+	case *Emphasis:
+		w.write(it.Delimiter)
+		if err := w.inlines(it.Inlines); err != nil {
+			return err
+		}
+		w.write(it.Delimiter)
+	case *FootnoteReference:
+		w.write(it.TextContent())
+	case *Strikethrough:
+		w.write(it.Delimiter)
+		if err := w.inlines(it.Inlines); err != nil {
+			return err
+		}
+		w.write(it.Delimiter)
+	case *Link:
+		switch it.Style {
+		case Autolink:
+			w.write("<" + it.TextContent() + ">")
+		case ExtendedAutolink:
+			w.text(it.TextContent())
+		default:
+			w.write("[")
+			if err := w.inlines(it.Inlines); err != nil {
+				return err
+			}
+			w.linkTail(it.Style, it.Label, it.Link, it.Title)
+		}
+		w.attributes(it.Attributes)
+	case *Image:
+		w.write("![")
+		if err := w.inlines(it.Inlines); err != nil {
+			return err
+		}
+		w.linkTail(it.Style, it.Label, it.Link, it.Title)
+		w.attributes(it.Attributes)
+	}
+	return nil
+}
+
+// attributes writes the attribute list of a link or an image, if any.
+func (w *markdownInlineWriter) attributes(attrs Attributes) {
+	w.write(markdownAttributes(attrs))
+	w.afterLink = true
+}
+
+// linkTail writes what follows the text of a link or the description of an image.
+func (w *markdownInlineWriter) linkTail(style LinkStyle, label string, dest string, title string) {
+	switch style {
+	case ShortcutReferenceLink:
+		w.write("]")
+		w.afterShortcut = true
+	case CollapsedReferenceLink:
+		w.write("][]")
+	case FullReferenceLink:
+		label = strings.TrimSuffix(strings.TrimPrefix(label, "["), "]")
+		w.write("][" + escapeMarkdown(label, `\[]`) + "]")
+	default:
+		w.write("](" + markdownDestination(dest))
+		if title != "" {
+			w.write(" " + markdownTitle(title))
+		}
+		w.write(")")
+	}
+}
+
+// text writes s, escaping what would be parsed as markup.
+func (w *markdownInlineWriter) text(s string) {
+	if s == "" {
+		return
+	}
+
+	var sb strings.Builder
+	lineStart := w.lineStart
+
+	// A link destination or a link label would follow a shortcut reference link.
+	if w.afterShortcut && s[0] == '(' {
+		sb.WriteByte('\\')
+	}
+
+	// An attribute list would follow a link or an image.
+	if w.afterLink && s[0] == '{' && w.exts&ExtAttributes != 0 {
+		sb.WriteByte('\\')
+	}
+
+	// A delimiter row would turn the line before it into a table header.
+	delimiterRow := false
+
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if lineStart && w.exts&ExtTable != 0 {
+			line := s[i:]
+			if j := strings.IndexByte(line, '\n'); j >= 0 {
+				line = line[:j]
+			}
+			_, delimiterRow = parseTableDelimiterRow([]rune(line))
+		}
+		switch r {
+		case '\\', '`', '*', '_', '~', '[', ']', '<':
+			sb.WriteByte('\\')
+		case '$':
+			if w.exts&ExtMath != 0 {
+				sb.WriteByte('\\')
+			}
+		case '&':
+			if i+1 < len(s) && (s[i+1] == '#' || isAlNum(rune(s[i+1]))) {
+				sb.WriteByte('\\')
+			}
+		case '#', '>', '-', '+', '=':
+			if lineStart || r == '-' && delimiterRow {
+				sb.WriteByte('\\')
+				delimiterRow = false
+			}
+		case ':':
+			// An emoji shortcode, a description of a definition list, or a fenced div fence.
+			if w.exts&ExtEmoji != 0 && (i == 0 || !isAlNum(rune(s[i-1]))) && isEmojiShortcode(s[i:]) ||
+				w.exts&ExtDefinitionList != 0 && lineStart && (i+1 == len(s) || strings.IndexByte(" \t\n", s[i+1]) >= 0) ||
+				w.exts&ExtFencedDiv != 0 && lineStart && strings.HasPrefix(s[i:], ":::") {
+				sb.WriteByte('\\')
+			}
+		case '{':
+			// A line of only an attribute list.
+			if w.exts&ExtAttributes != 0 && lineStart && isAttributeLine(s[i:]) {
+				sb.WriteByte('\\')
+			}
+		case '\n', ' ', '\t':
+			// A line ending in a text is from a character reference,
+			// and so are spaces and tabs that would be stripped at the start of a line.
+			if r == '\n' || lineStart {
+				sb.WriteString("&#" + strconv.Itoa(int(r)) + ";")
+				lineStart = false
+				i += n
+				continue
+			}
+		}
+
+		// An ordered list marker.
+		if lineStart && '0' <= r && r <= '9' {
+			j := i
+			for j < len(s) && '0' <= s[j] && s[j] <= '9' {
+				j++
+			}
+			if j < len(s) && (s[j] == '.' || s[j] == ')') {
+				sb.WriteString(s[i:j])
+				sb.WriteByte('\\')
+				i, r, n = j, rune(s[j]), 1
+			}
+		}
+
+		sb.WriteRune(r)
+		lineStart = r == '\n'
+		i += n
+	}
+
+	w.write(sb.String())
+}
+
+// startsHtmlBlock tells whether a line starting with tag starts an HTML block
+// that can interrupt a paragraph.
+func startsHtmlBlock(tag string) bool {
+	hb := tryParseHtmlBlock(nil, []rune(tag))
+	return hb != nil && hb.condition != 7
+}
+
+// codeSpan writes a code span of content, which has no line endings.
+func (w *markdownInlineWriter) codeSpan(content string) {
+	// The backtick string must not be found in the content.
+	runs := make(map[int]bool)
+	for i := 0; i < len(content); {
+		j := i
+		for j < len(content) && content[j] == '`' {
+			j++
+		}
+		if j > i {
+			runs[j-i] = true
+			i = j
+		} else {
+			i++
+		}
+	}
+	n := 1
+	for runs[n] {
+		n++
+	}
+	fence := strings.Repeat("`", n)
+
+	// One space is stripped from both sides, if the content is not all spaces.
+	pad := strings.HasPrefix(content, "`") || strings.HasSuffix(content, "`") ||
+		strings.HasPrefix(content, " ") && strings.HasSuffix(content, " ") && strings.Trim(content, " ") != ""
+	if pad {
+		content = " " + content + " "
+	}
+
+	w.write(fence + content + fence)
+}
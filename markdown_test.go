@@ -0,0 +1,120 @@
+package taomd
+
+import (
+	"strings"
+	"testing"
+)
+
+// testRoundTrip tests that rendering markdown as markdown and parsing it back
+// gives a document that renders to the same HTML.
+func testRoundTrip(t *testing.T, examples []*Example, opts ...Option) {
+	t.Helper()
+	for _, example := range examples {
+		doc := Parse(strings.NewReader(example.Markdown), opts...)
+		want := Render(doc)
+		md := RenderMarkdown(doc)
+		if given := Render(Parse(strings.NewReader(md), opts...)); given != want {
+			t.Errorf("example %d (%s):\nmarkdown:\n%s\nrendered:\n%s\nwant:\n%s\ngiven:\n%s",
+				example.Example, example.Section, example.Markdown, md, want, given)
+		}
+	}
+}
+
+func TestRenderMarkdownSpec(t *testing.T) {
+	testRoundTrip(t, loadExamples(t, "testdata/spec.json"), WithExtensions(CommonMark))
+}
+
+// testStable tests that markdown rendered from a document
+// is rendered as it is after being parsed again.
+func testStable(t *testing.T, examples []*Example, opts ...Option) {
+	t.Helper()
+	for _, example := range examples {
+		md := RenderMarkdown(Parse(strings.NewReader(example.Markdown), opts...))
+		if again := RenderMarkdown(Parse(strings.NewReader(md), opts...)); again != md {
+			t.Errorf("example %d (%s):\nmarkdown:\n%s\nrendered:\n%s\nrendered again:\n%s",
+				example.Example, example.Section, example.Markdown, md, again)
+		}
+	}
+}
+
+func TestRenderMarkdownStable(t *testing.T) {
+	testStable(t, loadExamples(t, "testdata/spec.json"), WithExtensions(CommonMark))
+	testStable(t, []*Example{
+		{Markdown: "- <script>\n\nfoo\n", Section: "HTML blocks"},
+		{Markdown: "- <pre>\n\n  a\n\nfoo\n", Section: "HTML blocks"},
+		{Markdown: "1. <style>\n\n\n2. x\n", Section: "HTML blocks"},
+		{Markdown: "~~~ ~x\nfoo\n~~~\n\nbar\n", Section: "Fenced code blocks"},
+		{Markdown: "``` `\nfoo\n", Section: "Fenced code blocks"},
+	}, WithExtensions(CommonMark))
+	testStable(t, []*Example{
+		{Markdown: "a\n: <pre>\n\n\nb\n: c\n", Section: "Definition lists"},
+		{Markdown: "a\n: <pre>\n\n: c\n", Section: "Definition lists"},
+	}, WithExtensions(ExtDefinitionList))
+}
+
+func TestRenderMarkdownRoundTrip(t *testing.T) {
+	testRoundTrip(t, []*Example{
+		{Markdown: "foo\n\tbar\n  \t baz\n", Section: "Paragraphs"},
+		{Markdown: "- foo\n\tbar\n", Section: "Paragraphs"},
+		{Markdown: "foo\n    </div>\n", Section: "Paragraphs"},
+		{Markdown: "- foo\n  bar\n      <div>\n", Section: "Paragraphs"},
+		{Markdown: "a\\\n      \nb\n", Section: "Blank lines"},
+		{Markdown: "  - nested2\n    - d\n\n    code\n", Section: "Lists"},
+		{Markdown: "- a\n  - b\n\n  c\n- d\n", Section: "Lists"},
+		{Markdown: "*\n    </div>\n", Section: "Lists"},
+		{Markdown: "- <div>\n\n  - b\n", Section: "Lists"},
+		{Markdown: "a\n> ===\n", Section: "Setext headings"},
+		{Markdown: "a\n- ===\n", Section: "Setext headings"},
+		{Markdown: "foo&#10;bar&#9;baz\n&#32;&#9;qux\n", Section: "Entity and numeric character references"},
+		{Markdown: "\\![foo]\n\n[foo]: /url\n", Section: "Links"},
+		{Markdown: "~~~ ~x\nfoo\n~~~\n\nbar\n", Section: "Fenced code blocks"},
+		{Markdown: "~~~ ~~~\nfoo\n~~~\n", Section: "Fenced code blocks"},
+		{Markdown: "- <script>\n\nfoo\n", Section: "HTML blocks"},
+	}, WithExtensions(CommonMark))
+}
+
+// unknownBlock is a block that cannot be rendered.
+type unknownBlock struct {
+	Span
+}
+
+func (b *unknownBlock) Kind() Kind                       { return Kind(-1) }
+func (b *unknownBlock) AddLine(p *Parser, s []rune) bool { return false }
+
+func TestRenderMarkdownError(t *testing.T) {
+	doc := Parse(strings.NewReader("# foo\n\nbar\n"))
+	doc.SetBlocks(append(doc.Blocks(), &unknownBlock{}))
+
+	var sb strings.Builder
+	err := RenderMarkdownTo(&sb, doc)
+	if _, ok := err.(*InternalError); !ok {
+		t.Fatalf("error: %v", err)
+	}
+	if want := "# foo\n\nbar\n"; sb.String() != want || RenderMarkdown(doc) != want {
+		t.Errorf("want partial output %q, given %q", want, sb.String())
+	}
+}
+
+func TestRenderMarkdownEscapes(t *testing.T) {
+	all := ExtMath | ExtEmoji | ExtDefinitionList | ExtFencedDiv | ExtAttributes
+	tests := []struct {
+		markdown string
+		exts     Extension
+		want     string
+	}{
+		{"\\$5 and \\:smile\\:\n", CommonMark, "$5 and :smile:\n"},
+		{"\\: a\n\n\\::: b\n", CommonMark, ": a\n\n::: b\n"},
+		{"\\{.c}\n", CommonMark, "{.c}\n"},
+		{"\\$5 and \\:smile\\:\n", all, "\\$5 and \\:smile:\n"},
+		{"\\: a\n\n\\::: b\n", all, "\\: a\n\n\\::: b\n"},
+		{"\\{.c}\n", all, "\\{.c}\n"},
+		{"`a`\n    |---|\n", CommonMark, "`a`\n|---|\n"},
+		{"`a`\n    |---|\n", ExtTable, "`a`\n|\\---|\n"},
+	}
+	for _, test := range tests {
+		doc := Parse(strings.NewReader(test.markdown), WithExtensions(test.exts))
+		if md := RenderMarkdown(doc); md != test.want {
+			t.Errorf("%q: want %q, given %q", test.markdown, test.want, md)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package taomd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A MathInline is a TeX formula in a paragraph, as in "$E = mc^2$",
+// or in display style, as in "$$\sum_i x_i$$".
+//
+// Like a code span, its content is taken as it is,
+// without backslash escapes, entities or emphases.
+type MathInline struct {
+	Span
+
+	// Is it enclosed in "$$" instead of "$"?
+	Display bool
+
+	// The TeX source, with line endings converted to spaces.
+	Literal string
+}
+
+// TextContent returns the TeX source.
+func (m *MathInline) TextContent() string {
+	return m.Literal
+}
+
+// A MathBlock is a TeX formula in display style on lines of its own,
+// fenced by lines of "$$", or in a fenced code block with the info string "math".
+type MathBlock struct {
+	Span
+	Attributes
+
+	// The fence it is written with, '$' or a code fence.
+	FenceMarker rune
+	FenceLength int
+
+	lines []string
+}
+
+// Lines returns the TeX source, line by line, with line endings.
+func (mb *MathBlock) Lines() []string {
+	return mb.lines
+}
+
+// SetLines replaces the TeX source. Each line should end with a line ending.
+func (mb *MathBlock) SetLines(lines []string) {
+	mb.lines = lines
+}
+
+// String returns the TeX source.
+func (mb *MathBlock) String() string {
+	return strings.Join(mb.lines, "")
+}
+
+func (mb *MathBlock) AddLine(p *Parser, s []rune) bool {
+	return false
+}
+
+// tryParseMathFence parses a line of two or more dollar signs ($),
+// and nothing else, which starts a math block.
+//
+// The block is a fenced code block until it is closed, and
+// is made a MathBlock after the whole document is parsed.
+func tryParseMathFence(c []rune, indent int) *CodeBlock {
+	n := 0
+	for n < len(c) && c[n] == '$' {
+		n++
+	}
+	if n < 2 || !isBlankLine(c[n:]) {
+		return nil
+	}
+	return &CodeBlock{
+		FenceMarker: '$',
+		FenceLength: n,
+		fenceIndent: indent,
+	}
+}
+
+// parseMathBlocks makes math blocks of the code blocks fenced by dollar signs
+// and the fenced code blocks with the info string "math".
+func (doc *Document) parseMathBlocks() {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		c, ok := n.(blockContainer)
+		if !ok || !entering {
+			return WalkContinue
+		}
+		blocks := c.Blocks()
+		for i, block := range blocks {
+			cb, ok := block.(*CodeBlock)
+			if !ok || !cb.isFenced() || cb.FenceMarker != '$' && cb.Info != "math" {
+				continue
+			}
+			blocks[i] = &MathBlock{
+				Span:        cb.Span,
+				Attributes:  cb.Attributes,
+				FenceMarker: cb.FenceMarker,
+				FenceLength: cb.FenceLength,
+				lines:       cb.lines,
+			}
+		}
+		return WalkContinue
+	})
+}
+
+// tryParseMath parses inline math at the start of c, and returns what follows it.
+//
+// The opening "$" must be followed by a non-space character, and the closing "$"
+// must be preceded by a non-space character and not followed by a digit,
+// so that "$5 and $10" is not math. "$$" is not restricted.
+func tryParseMath(c []rune) ([]rune, *MathInline) {
+	n := 0
+	for n < len(c) && c[n] == '$' {
+		n++
+	}
+	if n > 2 || n >= len(c) {
+		return c, nil
+	}
+	display := n == 2
+	if !display && unicode.IsSpace(c[n]) {
+		return c, nil
+	}
+
+	for i := n; i < len(c); i++ {
+		switch {
+		case c[i] == '\\':
+			// "\$" does not close the math, for it is a TeX escape.
+			i++
+			continue
+		case c[i] != '$':
+			continue
+		}
+		j := i
+		for j < len(c) && c[j] == '$' {
+			j++
+		}
+		if j-i != n {
+			i = j - 1
+			continue
+		}
+		if !display && (unicode.IsSpace(c[i-1]) || j < len(c) && unicode.IsDigit(c[j])) {
+			continue
+		}
+		if i == n {
+			return c, nil
+		}
+		return c[j:], &MathInline{
+			Display: display,
+			Literal: strings.Replace(string(c[n:i]), "\n", " ", -1),
+		}
+	}
+	return c, nil
+}
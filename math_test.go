@@ -0,0 +1,56 @@
+package taomd
+
+import (
+	"testing"
+)
+
+var mathExamples = []*Example{
+	{
+		Markdown: "$E = mc^2$ and $$\\sum_i x_i$$\n",
+		HTML:     "<p><span class=\"math inline\">\\(E = mc^2\\)</span> and <span class=\"math display\">\\[\\sum_i x_i\\]</span></p>\n",
+		Section:  "Math",
+	},
+	{
+		Markdown: "$5 and $10\n",
+		HTML:     "<p>$5 and $10</p>\n",
+		Section:  "Math",
+	},
+	{
+		Markdown: "$ x$ and $x $\n",
+		HTML:     "<p>$ x$ and $x $</p>\n",
+		Section:  "Math",
+	},
+	{
+		Markdown: "$a_*b*$ `$c$`\n",
+		HTML:     "<p><span class=\"math inline\">\\(a_*b*\\)</span> <code>$c$</code></p>\n",
+		Section:  "Math",
+	},
+	{
+		Markdown: "$$\nx^2\n$$\n",
+		HTML:     "<div class=\"math display\">\\[\nx^2\n\\]</div>\n",
+		Section:  "Math",
+	},
+	{
+		Markdown: "```math\nx < y\n```\n",
+		HTML:     "<div class=\"math display\">\\[\nx &lt; y\n\\]</div>\n",
+		Section:  "Math",
+	},
+	{
+		Markdown: "\\$x$\n",
+		HTML:     "<p>$x$</p>\n",
+		Section:  "Math",
+	},
+	{
+		Markdown: "- $$\n  x\n  $$\n",
+		HTML:     "<ul>\n<li>\n<div class=\"math display\">\\[\nx\n\\]</div>\n</li>\n</ul>\n",
+		Section:  "Math",
+	},
+}
+
+func TestMath(t *testing.T) {
+	testExamples(t, mathExamples, WithExtensions(ExtMath))
+}
+
+func TestMathRoundTrip(t *testing.T) {
+	testRoundTrip(t, mathExamples, WithExtensions(ExtMath))
+}
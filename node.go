@@ -6,40 +6,148 @@ import (
 	"unicode"
 )
 
+// A Node is a block or an inline of a parsed document.
+type Node interface {
+	// Pos returns where the node is in the markdown source.
+	Pos() Span
+
+	// Kind returns the kind of the node.
+	Kind() Kind
+}
+
+// A Container is a node that contains other nodes.
+type Container interface {
+	Node
+
+	// Children returns the nodes directly contained, in document order.
+	Children() []Node
+}
+
 type Blocker interface {
+	Node
 	AddLine(p *Parser, s []rune) bool
 }
 
+// blockContainer is implemented by blocks that contain other blocks.
+type blockContainer interface {
+	Blocks() []Blocker
+	SetBlocks(blocks []Blocker)
+}
+
 type Document struct {
-	blocks []Blocker
-	links  map[string]*LinkReferenceDefinition
+	Span
+
+	blocks      []Blocker
+	links       map[string]*LinkReferenceDefinition
+	definitions []*LinkReferenceDefinition // links in order
+	extensions  Extension
+	frontMatter *FrontMatter
+
+	footnotes    map[string]*FootnoteDefinition
+	footnoteList []*FootnoteDefinition // referenced footnotes in order
+}
+
+// Blocks returns the top-level blocks of the document.
+func (doc *Document) Blocks() []Blocker {
+	return doc.blocks
+}
+
+// Definitions returns the link reference definitions in the order they are defined.
+// A definition is dropped if an earlier one has a matching label.
+func (doc *Document) Definitions() []*LinkReferenceDefinition {
+	return doc.definitions
+}
+
+// SetBlocks replaces the top-level blocks of the document.
+func (doc *Document) SetBlocks(blocks []Blocker) {
+	doc.blocks = blocks
+}
+
+// Children returns the top-level blocks of the document.
+func (doc *Document) Children() []Node {
+	return blocksToNodes(doc.blocks)
+}
+
+// blocksToNodes converts blocks to nodes.
+func blocksToNodes(blocks []Blocker) []Node {
+	nodes := make([]Node, len(blocks))
+	for i, block := range blocks {
+		nodes[i] = block
+	}
+	return nodes
+}
+
+// inlinesToNodes converts inlines to nodes.
+func inlinesToNodes(inlines []Inline) []Node {
+	nodes := make([]Node, len(inlines))
+	for i, inline := range inlines {
+		nodes[i] = inline
+	}
+	return nodes
 }
 
 func (doc *Document) AddLine(p *Parser, s []rune) bool {
 	p.reset(s)
 	p.tip = doc
-	return addLine(&doc.blocks, s)
+	return p.addLine(&doc.blocks, s)
 }
 
-func (doc *Document) parseInlines() {
+func (doc *Document) parseInlines(p *Parser) {
 	for _, block := range doc.blocks {
-		if inliner, ok := block.(_Inliner); ok {
-			inliner.parseInlines()
-		}
+		Walk(block, func(n Node, entering bool) WalkStatus {
+			if inliner, ok := n.(_Inliner); ok && entering {
+				inliner.parseInlines(p)
+				return WalkSkipChildren
+			}
+			return WalkContinue
+		})
 	}
 }
 
 func (doc *Document) parseDefinitions() {
-	for _, block := range doc.blocks {
-		switch t := block.(type) {
-		case *Paragraph:
-			t.parseDefinitions()
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		if pp, ok := n.(*Paragraph); ok && entering {
+			pp.parseDefinitions(doc)
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+}
+
+// resolveTightness resolves whether lists and definition lists are tight, and makes
+// paragraphs directly in list items and descriptions as tight as their lists,
+// so that rendering needs not.
+func (doc *Document) resolveTightness() {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		if !entering {
+			return WalkContinue
+		}
+		switch l := n.(type) {
 		case *List:
-			t.parseDefinitions()
-		case *BlockQuote:
-			t.parseDefinitions()
+			l.deduceIsTight()
+			for _, item := range l.Items {
+				if li, ok := item.(*ListItem); ok {
+					for _, block := range li.blocks {
+						if pp, ok := block.(*Paragraph); ok {
+							pp.Tight = l.Tight
+						}
+					}
+				}
+			}
+		case *DefinitionList:
+			l.deduceIsTight()
+			for _, item := range l.Items {
+				if dd, ok := item.(*DefinitionDescription); ok {
+					for _, block := range dd.blocks {
+						if pp, ok := block.(*Paragraph); ok {
+							pp.Tight = l.Tight
+						}
+					}
+				}
+			}
 		}
-	}
+		return WalkContinue
+	})
 }
 
 func (doc *Document) refLink(label string, enclosed bool) (string, string, bool) {
@@ -55,15 +163,26 @@ func (doc *Document) refLink(label string, enclosed bool) (string, string, bool)
 }
 
 type BlankLine struct {
+	Span
 }
 
 func (bl *BlankLine) AddLine(p *Parser, s []rune) bool {
 	return false
 }
 
+// newBlankLine makes a BlankLine for s of the current line.
+func (p *Parser) newBlankLine(s []rune) *BlankLine {
+	bl := &BlankLine{}
+	p.startBlock(bl, s)
+	return bl
+}
+
 // HorizontalRule is a horizontal rule (thematic breaks).
 // https://spec.commonmark.org/0.29/#thematic-breaks
 type HorizontalRule struct {
+	Span
+	Attributes
+
 	Marker rune
 
 	// temp for parse as setext heading
@@ -75,11 +194,19 @@ func (hr *HorizontalRule) AddLine(p *Parser, s []rune) bool {
 }
 
 type Paragraph struct {
+	Span
+	Attributes
+
 	texts   []string
+	starts  []Position // where each of texts starts
 	Tight   bool
 	Inlines []Inline
 	closed  bool
 	lazying bool
+
+	// The paragraph is a line of only an attribute list,
+	// whose attributes are of the block after it.
+	nextAttributes Attributes
 }
 
 func (pp *Paragraph) AddLine(p *Parser, s []rune) bool {
@@ -87,10 +214,17 @@ func (pp *Paragraph) AddLine(p *Parser, s []rune) bool {
 		return false
 	}
 
+	// A description interrupts a paragraph, which is made its terms.
+	if p.enabled(ExtDefinitionList) && !pp.lazying && isDescriptionStart(s) {
+		pp.closed = true
+		return false
+	}
+
 	var blocks []Blocker
 	p.tip = pp
-	if !addLine(&blocks, s) {
-		panic("won't happen")
+	if !p.addLine(&blocks, s) {
+		p.fail(internalErrorf("paragraph continuation line not added"))
+		return false
 	}
 
 	// A Link Reference Definition was added.
@@ -98,48 +232,58 @@ func (pp *Paragraph) AddLine(p *Parser, s []rune) bool {
 		return true
 	}
 
-	// Leading spaces at the beginning of the next line are ignored
-	trimLeft := func(s string) string {
-		return strings.TrimLeft(s, " ")
+	// Leading spaces and tabs at the beginning of the next line are ignored
+	trimLeft := func(s []rune) []rune {
+		for len(s) > 0 && (s[0] == ' ' || s[0] == '\t') {
+			s = s[1:]
+		}
+		return s
 	}
 
 	switch typed := blocks[0].(type) {
 	case *Paragraph:
 		// A sequence of non-blank lines that cannot be interpreted as ...
 		// ... other kinds of blocks forms a paragraph.
-		pp.texts = append(pp.texts, trimLeft(typed.texts[0]))
+		text := typed.texts[0]
+		start := typed.starts[0]
+		for len(text) > 0 && (text[0] == ' ' || text[0] == '\t') {
+			text = text[1:]
+			start.Column++
+			start.Offset++
+		}
+		pp.addText(p, text, start)
 		return true
 	case *CodeBlock:
 		// An indented code block cannot interrupt a paragraph
 		if !typed.isFenced() {
 			// s: typed.lines[0] is trimmed 4 spaces at the beginning, don't use.
-			pp.texts = append(pp.texts, trimLeft(string(s)))
+			pp.addLineText(p, trimLeft(s))
 			return true
 		}
 	case *List:
 		// In order to solve of unwanted lists in paragraphs with hard-wrapped numerals,
 		// we allow only lists starting with 1 to interrupt paragraphs.
 		if typed.Ordered && typed.Start != 1 {
-			pp.texts = append(pp.texts, trimLeft(string(s)))
+			pp.addLineText(p, trimLeft(s))
 			return true
 		}
 		// However, an empty list item cannot interrupt a paragraph
 		if len(typed.Items) == 1 {
 			if subItem, ok := typed.Items[0].(*ListItem); ok {
 				if len(subItem.blocks) == 0 {
-					pp.texts = append(pp.texts, trimLeft(string(s)))
+					pp.addLineText(p, trimLeft(s))
 					return true
 				}
 			}
 		}
 	case *LinkReferenceDefinition:
 		// A link reference definition cannot interrupt a paragraph.
-		pp.texts = append(pp.texts, trimLeft(string(s)))
+		pp.addLineText(p, trimLeft(s))
 		return true
 	case *HtmlBlock:
 		// HTML blocks of type 7 cannot interrupt a paragraph
 		if typed.condition == 7 {
-			pp.texts = append(pp.texts, trimLeft(string(s)))
+			pp.addLineText(p, trimLeft(s))
 			return true
 		}
 	}
@@ -149,36 +293,80 @@ func (pp *Paragraph) AddLine(p *Parser, s []rune) bool {
 	return false
 }
 
-func (pp *Paragraph) parseDefinitions() bool {
+// addText adds a line of text, which starts at start, to the paragraph.
+func (pp *Paragraph) addText(p *Parser, text string, start Position) {
+	pp.texts = append(pp.texts, text)
+	pp.starts = append(pp.starts, start)
+	pp.End = p.lineEnd()
+}
+
+// addLineText adds s, the rest of the current line, to the paragraph.
+func (pp *Paragraph) addLineText(p *Parser, s []rune) {
+	pp.addText(p, string(s), p.posOf(s))
+}
+
+func (pp *Paragraph) parseDefinitions(doc *Document) bool {
 	raw := []rune(strings.Join(pp.texts, ""))
+	m := newSourceMap(raw, pp.starts)
+	consumed := 0
 	for {
-		remain, link := tryParseLinkReferenceDefinition(raw)
+		remain, link := tryParseLinkReferenceDefinition(raw[consumed:])
 		if link == nil {
 			break
 		}
 
+		n := len(raw) - len(remain)
+		link.Span = m.span(consumed, n)
+		consumed = n
+
 		lower := strings.ToLower(link.Label)
-		if _, ok := p.doc.links[lower]; !ok {
-			p.doc.links[lower] = link
+		if _, ok := doc.links[lower]; !ok {
+			doc.links[lower] = link
+			doc.definitions = append(doc.definitions, link)
+		}
+	}
+	if consumed > 0 {
+		pp.starts = m.skip(raw, pp.starts, consumed)
+		pp.Start = m[consumed]
+		// Nothing left, the paragraph is empty.
+		if pp.Start.Offset > pp.End.Offset {
+			pp.Start = pp.End
 		}
-
-		raw = remain
 	}
-	pp.texts = []string{string(raw)}
-	return len(raw) == 0
+	pp.texts = []string{string(raw[consumed:])}
+	return consumed == len(raw)
 }
 
 func (pp *Paragraph) addLaziness(p *Parser, s []rune) bool {
-	_, s = skipPrefixSpaces(s, -1)
+	// The indentation is kept, for an indented line is a paragraph
+	// continuation line, rather than the start of a list, for example.
 	pp.lazying = true
 	defer func() { pp.lazying = false }()
 	return pp.AddLine(p, s)
 }
 
-func (pp *Paragraph) parseInlines() {
+func (pp *Paragraph) parseInlines(p *Parser) {
 	raw := strings.Join(pp.texts, "")
-	raw = strings.TrimSpace(raw)
-	pp.Inlines = parseInlines(raw)
+	pp.Inlines = p.parseInlines(raw, pp.starts)
+}
+
+// Lines returns the raw text of the paragraph, line by line,
+// with leading spaces of each line and link reference definitions removed.
+func (pp *Paragraph) Lines() []string {
+	var lines []string
+	for _, text := range pp.texts {
+		for _, line := range strings.SplitAfter(text, "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+// Children returns the inlines of the paragraph.
+func (pp *Paragraph) Children() []Node {
+	return inlinesToNodes(pp.Inlines)
 }
 
 type Line struct {
@@ -186,21 +374,47 @@ type Line struct {
 }
 
 type Heading struct {
+	Span
+	Attributes
+
 	Level   int
 	Inlines []Inline
 	text    string
+	starts  []Position // where each line of text starts
+
+	// A setext heading consists of one or more lines of text,
+	// followed by a setext heading underline. Otherwise it is an ATX heading.
+	Setext bool
+
+	// The id of the heading, if made by WithHeadingIDs.
+	ID         string
+	explicitID bool // written as "{#id}"
 }
 
 func (h *Heading) AddLine(p *Parser, s []rune) bool {
 	return false
 }
 
-func (h *Heading) parseInlines() {
-	text := strings.TrimSpace(h.text)
-	h.Inlines = parseInlines(text)
+func (h *Heading) parseInlines(p *Parser) {
+	h.Inlines = p.parseInlines(h.text, h.starts)
+}
+
+// TextContent returns the text of the heading without markups.
+func (h *Heading) TextContent() (s string) {
+	for _, inline := range h.Inlines {
+		s += textContent(inline)
+	}
+	return
+}
+
+// Children returns the inlines of the heading.
+func (h *Heading) Children() []Node {
+	return inlinesToNodes(h.Inlines)
 }
 
 type SetextHeading struct {
+	Span
+
 	line  []rune
 	level int
 }
@@ -209,8 +423,19 @@ func (h *SetextHeading) AddLine(p *Parser, s []rune) bool {
 	return false
 }
 
+// toParagraph makes a paragraph of the underline, for it has nothing to underline.
+func (h *SetextHeading) toParagraph() *Paragraph {
+	return &Paragraph{
+		Span:   h.Span,
+		texts:  []string{string(h.line)},
+		starts: []Position{h.Start},
+	}
+}
+
 // An HTML block is a group of lines that is treated as raw HTML (and will not be escaped in HTML output).
 type HtmlBlock struct {
+	Span
+
 	Lines [][]rune
 
 	condition int
@@ -280,37 +505,37 @@ func (hb *HtmlBlock) AddLine(p *Parser, c []rune) bool {
 }
 
 type BlockQuote struct {
+	Span
+	Attributes
+
 	blocks []Blocker
 }
 
-func (bq *BlockQuote) parseDefinitions() {
-	for _, block := range bq.blocks {
-		switch it := block.(type) {
-		case *Paragraph:
-			it.parseDefinitions()
-		case *List:
-			it.parseDefinitions()
-		}
-	}
+// Blocks returns the blocks in the block quote.
+func (bq *BlockQuote) Blocks() []Blocker {
+	return bq.blocks
+}
+
+// SetBlocks replaces the blocks in the block quote.
+func (bq *BlockQuote) SetBlocks(blocks []Blocker) {
+	bq.blocks = blocks
+}
+
+// Children returns the blocks in the block quote.
+func (bq *BlockQuote) Children() []Node {
+	return blocksToNodes(bq.blocks)
 }
 
 func (bq *BlockQuote) AddLine(p *Parser, s []rune) bool {
-	_, ok := tryParseBlockQuote(s, bq)
+	_, ok := p.tryParseBlockQuote(s, bq)
 	if ok {
-		tryMergeSetextHeading(&bq.blocks)
+		p.tryMergeSetextHeading(&bq.blocks)
+		p.tryMergeTableHeader(&bq.blocks)
 		return true
 	}
 	return bq.addLaziness(p, s)
 }
 
-func (bq *BlockQuote) parseInlines() {
-	for _, block := range bq.blocks {
-		if inliner, ok := block.(_Inliner); ok {
-			inliner.parseInlines()
-		}
-	}
-}
-
 func (bq *BlockQuote) addLaziness(p *Parser, s []rune) bool {
 	if len(bq.blocks) == 0 {
 		return false
@@ -322,6 +547,8 @@ func (bq *BlockQuote) addLaziness(p *Parser, s []rune) bool {
 		return typed.addLaziness(p, s)
 	case *Paragraph:
 		return typed.addLaziness(p, s)
+	case *DefinitionList:
+		return typed.addLaziness(p, s)
 	}
 	return false
 }
@@ -329,6 +556,9 @@ func (bq *BlockQuote) addLaziness(p *Parser, s []rune) bool {
 // A List is a sequence of one or more list items of the same type.
 // The list items may be separated by any number of blank lines.
 type List struct {
+	Span
+	Attributes
+
 	// A list is an ordered list if its constituent list items begin with ordered list markers,
 	// and a bullet list if its constituent list items begin with bullet list markers.
 	Ordered bool
@@ -357,6 +587,11 @@ type List struct {
 	closed bool
 }
 
+// Children returns the items of the list, and the blank lines between them.
+func (l *List) Children() []Node {
+	return blocksToNodes(l.Items)
+}
+
 func (l *List) parseMarker(s []rune) (remain []rune, list *List, prefixSpaces int, markerWidth int, ok bool) {
 	list = &List{}
 	prefixWidth := 0
@@ -424,24 +659,6 @@ func (l *List) parseMarker(s []rune) (remain []rune, list *List, prefixSpaces in
 	}
 }
 
-func (l *List) parseDefinitions() {
-	for _, item := range l.Items {
-		switch t := item.(type) {
-		case *ListItem:
-			for _, block := range t.blocks {
-				switch it := block.(type) {
-				case *Paragraph:
-					it.parseDefinitions()
-				case *List:
-					it.parseDefinitions()
-				case *BlockQuote:
-					it.parseDefinitions()
-				}
-			}
-		}
-	}
-}
-
 func (l *List) isHorizontalRule(s []rune) bool {
 	if _, s = skipPrefixSpaces(s, -1); len(s) == 0 {
 		return false
@@ -477,8 +694,9 @@ func (l *List) AddLine(p *Parser, s []rune) bool {
 
 	if lastItem != nil {
 		if lastItem.AddLine(p, s) {
+			p.extendBlock(lastItem, s)
 			if isBlankLine(s) {
-				l.Items = append(l.Items, &BlankLine{})
+				l.Items = append(l.Items, p.newBlankLine(s))
 				return true
 			}
 			return true
@@ -486,7 +704,7 @@ func (l *List) AddLine(p *Parser, s []rune) bool {
 
 		if isBlankLine(s) {
 			// lastItem.closed = true
-			l.Items = append(l.Items, &BlankLine{})
+			l.Items = append(l.Items, p.newBlankLine(s))
 			return true
 		}
 
@@ -496,7 +714,7 @@ func (l *List) AddLine(p *Parser, s []rune) bool {
 	}
 
 	if isBlankLine(s) {
-		l.Items = append(l.Items, &BlankLine{})
+		l.Items = append(l.Items, p.newBlankLine(s))
 		return true
 	}
 
@@ -538,6 +756,7 @@ func (l *List) AddLine(p *Parser, s []rune) bool {
 		prefixSpaces: prefixSpaces,
 		suffixSpaces: markerWidth,
 	}
+	p.startBlock(lastItem, os[prefixSpaces:])
 
 	l.Items = append(l.Items, lastItem)
 
@@ -547,13 +766,15 @@ func (l *List) AddLine(p *Parser, s []rune) bool {
 		return true
 	}
 
-	if addLine(&lastItem.blocks, s) {
+	// The rest of the line is not a paragraph continuation line.
+	p.tip = lastItem
+	if p.addLine(&lastItem.blocks, s) {
 		return true
 	}
 
 	_, s = skipPrefixSpaces(s, -1)
 	if len(s) == 0 || s[0] == '\n' {
-		l.Items = append(l.Items, &BlankLine{})
+		l.Items = append(l.Items, p.newBlankLine(s))
 		return true
 	}
 
@@ -595,13 +816,12 @@ func (l *List) deduceIsTight() {
 			for _, block := range pItem.blocks {
 				switch t := block.(type) {
 				default:
-					if setItemBlankLine != nil {
+					// The blank line may end a list nested in the block before.
+					if setItemBlankLine != nil || endsWithBlankLine(setItemBlock) {
 						l.Tight = false
 						return
 					}
-					if setItemBlock == nil {
-						setItemBlock = t
-					}
+					setItemBlock = t
 				case *BlankLine:
 					if setItemBlock != nil {
 						if setItemBlankLine == nil {
@@ -615,12 +835,22 @@ func (l *List) deduceIsTight() {
 	l.Tight = true
 }
 
-func (l *List) parseInlines() {
-	for _, item := range l.Items {
-		if inliner, ok := item.(_Inliner); ok {
-			inliner.parseInlines()
+// endsWithBlankLine tells whether the last line of block,
+// which may be in a list nested in it, is a blank line.
+func endsWithBlankLine(block Blocker) bool {
+	switch t := block.(type) {
+	case *BlankLine:
+		return true
+	case *List:
+		if n := len(t.Items); n > 0 {
+			return endsWithBlankLine(t.Items[n-1])
+		}
+	case *ListItem:
+		if n := len(t.blocks); n > 0 {
+			return endsWithBlankLine(t.blocks[n-1])
 		}
 	}
+	return false
 }
 
 func (l *List) addLaziness(p *Parser, s []rune) bool {
@@ -633,12 +863,32 @@ func (l *List) addLaziness(p *Parser, s []rune) bool {
 }
 
 type ListItem struct {
+	Span
+
+	// Task tells whether it is a task list item, and whether it is checked.
+	Task Task
+
 	prefixSpaces int
 	suffixSpaces int
 	blocks       []Blocker
 	closed       bool
 }
 
+// Blocks returns the blocks in the list item.
+func (li *ListItem) Blocks() []Blocker {
+	return li.blocks
+}
+
+// SetBlocks replaces the blocks in the list item.
+func (li *ListItem) SetBlocks(blocks []Blocker) {
+	li.blocks = blocks
+}
+
+// Children returns the blocks in the list item.
+func (li *ListItem) Children() []Node {
+	return blocksToNodes(li.blocks)
+}
+
 func (li *ListItem) addLaziness(p *Parser, s []rune) bool {
 	if len(li.blocks) > 0 {
 		switch typed := li.blocks[len(li.blocks)-1].(type) {
@@ -648,6 +898,8 @@ func (li *ListItem) addLaziness(p *Parser, s []rune) bool {
 			return typed.addLaziness(p, s)
 		case *BlockQuote:
 			return typed.addLaziness(p, s)
+		case *DefinitionList:
+			return typed.addLaziness(p, s)
 		}
 	}
 	return false
@@ -670,7 +922,7 @@ func (li *ListItem) AddLine(p *Parser, s []rune) bool {
 			return false
 		}
 
-		li.blocks = append(li.blocks, &BlankLine{})
+		li.blocks = append(li.blocks, p.newBlankLine(s))
 		return true
 	}
 	_, nSkipped := peekSpaces(s, li.prefixSpaces)
@@ -680,8 +932,9 @@ func (li *ListItem) AddLine(p *Parser, s []rune) bool {
 		return false
 	}
 	s = s[nSkipped:]
-	if addLine(&li.blocks, s) {
-		tryMergeSetextHeading(&li.blocks)
+	if p.addLine(&li.blocks, s) {
+		p.tryMergeSetextHeading(&li.blocks)
+		p.tryMergeTableHeader(&li.blocks)
 		li.tryMergeCodeBlock()
 		return true
 	}
@@ -707,22 +960,18 @@ func (li *ListItem) tryMergeCodeBlock() {
 					n--
 				}
 				prevCode.lines = append(prevCode.lines, lastCode.lines[0])
+				prevCode.End = lastCode.End
 				li.blocks = li.blocks[:j+1]
 			}
 		}
 	}
 }
 
-func (li *ListItem) parseInlines() {
-	for _, block := range li.blocks {
-		if inliner, ok := block.(_Inliner); ok {
-			inliner.parseInlines()
-		}
-	}
-}
-
 // CodeBlock is either a fenced code block or an indented code block.
 type CodeBlock struct {
+	Span
+	Attributes
+
 	// The line with the opening code fence may optionally contain some
 	// text following the code fence; this is trimmed of leading and
 	// trailing whitespace and called the info string.
@@ -740,7 +989,7 @@ type CodeBlock struct {
 
 	// A code fence is a sequence of at least three consecutive backtick
 	// characters (`) or tildes (~). (Tildes and backticks cannot be mixed.)
-	fenceMarker rune
+	FenceMarker rune
 
 	// A fenced code block begins with a code fence, indented no more than three spaces.
 	// If the leading code fence is indented N spaces, then up to N spaces of indentation
@@ -751,13 +1000,38 @@ type CodeBlock struct {
 	// until a closing code fence of the same type as the code block
 	// began with (backticks or tildes), and with at least as many backticks
 	// or tildes as the opening code fence.
-	fenceLength int
+	//
+	// It is 0 for an indented code block.
+	FenceLength int
 
 	closed bool
 }
 
+// Lines returns the content of the code block, line by line, with line endings.
+//
+// Blank lines preceding or following an indented code block are not included.
+func (cb *CodeBlock) Lines() []string {
+	if cb.isFenced() {
+		return cb.lines
+	}
+	i, j := 0, len(cb.lines)
+	for i < j && isBlankLine([]rune(cb.lines[i])) {
+		i++
+	}
+	for j > i && isBlankLine([]rune(cb.lines[j-1])) {
+		j--
+	}
+	return cb.lines[i:j]
+}
+
+// SetLines replaces the content of the code block.
+// Each line should end with a line ending.
+func (cb *CodeBlock) SetLines(lines []string) {
+	cb.lines = lines
+}
+
 func (cb *CodeBlock) isFenced() bool {
-	return cb.fenceLength > 0
+	return cb.FenceLength > 0
 }
 
 func (cb *CodeBlock) isClosingFence(s []rune) bool {
@@ -765,7 +1039,7 @@ func (cb *CodeBlock) isClosingFence(s []rune) bool {
 
 	// Closing fences may be indented by 0-3 spaces, and their
 	// indentation need not match that of the opening fence.
-	for i < 3 && isSpace(s[i]) {
+	for i < 3 && i < len(s) && isSpace(s[i]) {
 		i++
 	}
 
@@ -773,10 +1047,10 @@ func (cb *CodeBlock) isClosingFence(s []rune) bool {
 	// began with (backticks or tildes), and with at least as many backticks
 	// or tildes as the opening code fence.
 	n := i
-	for n < len(s) && s[n] == cb.fenceMarker {
+	for n < len(s) && s[n] == cb.FenceMarker {
 		n++
 	}
-	if n-i >= cb.fenceLength && isBlankLine(s[n:]) {
+	if n-i >= cb.FenceLength && isBlankLine(s[n:]) {
 		return true
 	}
 
@@ -792,7 +1066,7 @@ func (cb *CodeBlock) AddLine(p *Parser, s []rune) bool {
 		return cb.addLineFenced(s)
 	}
 
-	return cb.addLineIndented(s)
+	return cb.addLineIndented(p, s)
 }
 
 func (cb *CodeBlock) addLineFenced(s []rune) bool {
@@ -813,10 +1087,12 @@ func (cb *CodeBlock) addLineFenced(s []rune) bool {
 	return true
 }
 
-func (cb *CodeBlock) addLineIndented(s []rune) bool {
+func (cb *CodeBlock) addLineIndented(p *Parser, s []rune) bool {
+	p.advanceTo(s)
+	p.findNextNonspace()
 	if p.indented {
-		advanceOffset(4, true)
-		line := string(s[p.offset:])
+		p.advanceOffset(4, true)
+		line := string(p.line[p.offset:])
 		cb.lines = append(cb.lines, line)
 		return true
 	}
@@ -828,30 +1104,14 @@ func (cb *CodeBlock) addLineIndented(s []rune) bool {
 }
 
 func (cb *CodeBlock) String() string {
-	if cb.isFenced() {
-		return strings.Join(cb.lines, "")
-	}
-
-	// indented code block starts from a blank line.
-	// So, there must be at least one non-blank line.
-
-	// Blank lines preceding or following an indented code block are not included in it
-	// TODO optimize blank line checking
-	i, j := 0, len(cb.lines)-1
-	for isBlankLine([]rune(cb.lines[i])) {
-		i++
-	}
-	for isBlankLine([]rune(cb.lines[j])) {
-		j--
-	}
-	cb.lines = cb.lines[i : j+1]
-
-	return strings.Join(cb.lines, "")
+	return strings.Join(cb.Lines(), "")
 }
 
 // A LinkReferenceDefinition defines a label which can be used in reference links and reference-style images elsewhere in the document.
 // It does not correspond to a structural element of a document. Instead, it can come either before or after the links that use them.
 type LinkReferenceDefinition struct {
+	Span
+
 	// It consists of a link label, indented up to three spaces, followed by a colon (:)
 	Label string
 
@@ -870,10 +1130,12 @@ func (l *LinkReferenceDefinition) AddLine(p *Parser, c []rune) bool {
 /* INLINES BELOW */
 
 type _Inliner interface {
-	parseInlines()
+	parseInlines(p *Parser)
 }
 
+// An Inline is an inline node, such as a text, a link or an emphasis.
 type Inline interface {
+	Node
 }
 
 type ITextContent interface {
@@ -892,6 +1154,8 @@ func textContent(i interface{}) string {
 }
 
 type Text struct {
+	Span
+
 	Text string
 }
 
@@ -968,7 +1232,7 @@ func (d *Delimiter) isRightFlanking() bool {
 
 func (d *Delimiter) canOpenEmphasis() bool {
 	switch d.text[0] {
-	case '*':
+	case '*', '~':
 		return d.isLeftFlanking()
 	case '_':
 		return d.isLeftFlanking() && (!d.isRightFlanking() || isPunctuation(d.prevChar()))
@@ -978,7 +1242,7 @@ func (d *Delimiter) canOpenEmphasis() bool {
 
 func (d *Delimiter) canCloseEmphasis() bool {
 	switch d.text[0] {
-	case '*':
+	case '*', '~':
 		return d.isRightFlanking()
 	case '_':
 		return d.isRightFlanking() && (!d.isLeftFlanking() || isPunctuation(d.nextChar()))
@@ -991,6 +1255,10 @@ func (d *Delimiter) canBeStrong() bool {
 }
 
 func (d *Delimiter) match(closer *Delimiter) bool {
+	if d.text[0] == '~' {
+		// Strikethrough is wrapped in a matching pair of one or two tildes.
+		return d.text == closer.text
+	}
 	return d.text[0] == closer.text[0]
 }
 
@@ -1002,22 +1270,33 @@ func (d *Delimiter) oddMatch(closer *Delimiter) bool {
 		(len(opener.text)+len(closer.text))%3 == 0
 }
 
-// since delimiters in d are the same, from what end to remove is ignored.
-func (d *Delimiter) consume(count int) int {
+// consume removes count delimiters from the start of a closer or from the end of an opener.
+// Since delimiters in d are the same, this only matters to the span of the text.
+func (d *Delimiter) consume(count int, closing bool) int {
 	n := len(d.text)
 	if count > n {
-		panic("won't happen")
+		panic(internalErrorf("delimiter run %q has fewer than %d delimiters", d.text, count))
 	}
 
 	d.text = d.text[0 : n-count]
 	t := d.textElement.Value.(*Text)
 	t.Text = t.Text[0 : n-count]
 
+	if closing {
+		t.Start.Column += count
+		t.Start.Offset += count
+	} else {
+		t.End.Column -= count
+		t.End.Offset -= count
+	}
+
 	return len(d.text)
 }
 
 // A CodeSpan begins with a backtick string and ends with a backtick string of equal length.
 type CodeSpan struct {
+	Span
+
 	text string
 }
 
@@ -1051,11 +1330,48 @@ func (cs *CodeSpan) TextContent() string {
 	return text
 }
 
+// LinkStyle tells how a link or an image is written.
+type LinkStyle int
+
+const (
+	// InlineLink is written as [text](destination "title").
+	InlineLink LinkStyle = iota
+
+	// FullReferenceLink is written as [text][label].
+	FullReferenceLink
+
+	// CollapsedReferenceLink is written as [label][].
+	CollapsedReferenceLink
+
+	// ShortcutReferenceLink is written as [label].
+	ShortcutReferenceLink
+
+	// Autolink is written as <destination>.
+	Autolink
+
+	// ExtendedAutolink is written as the destination itself,
+	// without the scheme of a www autolink or the mailto: of an email autolink.
+	ExtendedAutolink
+)
+
 type Link struct {
+	Span
+	Attributes
+
 	Inlines []Inline
 
 	Link  string
 	Title string
+
+	Style LinkStyle
+
+	// The link label, in brackets, of a reference link.
+	Label string
+}
+
+// Children returns the inlines of the link text.
+func (l *Link) Children() []Node {
+	return inlinesToNodes(l.Inlines)
 }
 
 func (l *Link) TextContent() string {
@@ -1069,11 +1385,25 @@ func (l *Link) TextContent() string {
 }
 
 type Image struct {
+	Span
+	Attributes
+
 	Link  string
 	Alt   string
 	Title string
 
-	inlines []Inline
+	// The image description, from which Alt is made.
+	Inlines []Inline
+
+	Style LinkStyle
+
+	// The link label, in brackets, of a reference image.
+	Label string
+}
+
+// Children returns the inlines of the image description.
+func (i *Image) Children() []Node {
+	return inlinesToNodes(i.Inlines)
 }
 
 func (i *Image) TextContent() string {
@@ -1081,17 +1411,41 @@ func (i *Image) TextContent() string {
 }
 
 type Emphasis struct {
+	Span
+
 	Delimiter string
 	Inlines   []Inline
 }
 
+// Children returns the emphasized inlines.
+func (e *Emphasis) Children() []Node {
+	return inlinesToNodes(e.Inlines)
+}
+
 func (e *Emphasis) TextContent() (s string) {
 	for _, i := range e.Inlines {
-		tc, ok := i.(ITextContent)
-		if !ok {
-			panic("implement ITextContent")
-		}
-		s += tc.TextContent()
+		s += textContent(i)
+	}
+	return
+}
+
+// Strikethrough is text wrapped in a matching pair of one or two tildes (~).
+// https://github.github.com/gfm/#strikethrough-extension-
+type Strikethrough struct {
+	Span
+
+	Delimiter string
+	Inlines   []Inline
+}
+
+// Children returns the struck inlines.
+func (st *Strikethrough) Children() []Node {
+	return inlinesToNodes(st.Inlines)
+}
+
+func (st *Strikethrough) TextContent() (s string) {
+	for _, i := range st.Inlines {
+		s += textContent(i)
 	}
 	return
 }
@@ -1099,7 +1453,9 @@ func (e *Emphasis) TextContent() (s string) {
 // A HardLineBreak is a line break (not in a code span or HTML tag) that is preceded
 // by two or more spaces and does not occur at the end of a block is parsed as a
 // hard line break (rendered in HTML as a <br /> tag).
-type HardLineBreak struct{}
+type HardLineBreak struct {
+	Span
+}
 
 // A SoftLineBreak is a regular line break (not in a code span or HTML tag) that is not preceded
 // by two or more spaces or a backslash is parsed as a softbreak.
@@ -1108,7 +1464,9 @@ type HardLineBreak struct{}
 // The result will be the same in browsers. In the examples, a line ending will be used.
 //
 // A renderer may also provide an option to render soft line breaks as hard line breaks.
-type SoftLineBreak struct{}
+type SoftLineBreak struct {
+	Span
+}
 
 // An HtmlTag (HTML tag) consists of an open tag, a closing tag, an HTML comment,
 // a processing instruction, a declaration, or a CDATA section.
@@ -1118,6 +1476,8 @@ type SoftLineBreak struct{}
 // Tag and attribute names are not limited to current HTML tags,
 // so custom tags (and even, say, DocBook tags) may be used.
 type HtmlTag struct {
+	Span
+
 	Tag string
 }
 
@@ -0,0 +1,52 @@
+package taomd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlocks(t *testing.T) {
+	doc := Parse(strings.NewReader("# a\n\n> b\n"))
+
+	// The top-level blocks, with the blank line between them.
+	blocks := doc.Blocks()
+	if len(blocks) != 3 {
+		t.Fatalf("blocks: %v", blocks)
+	}
+	h, ok := blocks[0].(*Heading)
+	if !ok || h.Level != 1 || h.Inlines[0].(*Text).Text != "a" {
+		t.Fatalf("heading: %#v", blocks[0])
+	}
+	bq, ok := blocks[2].(*BlockQuote)
+	if !ok || len(bq.Blocks()) != 1 {
+		t.Fatalf("block quote: %#v", blocks[2])
+	}
+
+	// Blocks are changed in place and rendered as they are.
+	h.Level = 2
+	bq.SetBlocks(append(bq.Blocks(), &HorizontalRule{Marker: '*'}))
+	doc.SetBlocks([]Blocker{bq, h})
+	want := "<blockquote>\n<p>b</p>\n<hr />\n</blockquote>\n<h2>a</h2>\n"
+	if html := Render(doc); html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+}
+
+func TestChildren(t *testing.T) {
+	doc := Parse(strings.NewReader("- *a* b\n"))
+	var kinds []string
+	var walk func(n Node)
+	walk = func(n Node) {
+		kinds = append(kinds, n.Kind().String())
+		if c, ok := n.(Container); ok {
+			for _, child := range c.Children() {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+	want := "Document List ListItem Paragraph Emphasis Text Text"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Errorf("want %s, given %s", want, got)
+	}
+}
@@ -12,6 +12,19 @@ import (
 // Parser is a markdown parser.
 type Parser struct {
 	doc *Document
+	err error
+
+	// Options
+	maxSize      int
+	transformers []prioritizedTransformer
+	extensions   Extension
+	slugger      Slugger
+	tocMarker    string
+	tocMinLevel  int
+	tocMaxLevel  int
+	alertKinds   map[string]string
+
+	frontMatterDecoders map[FrontMatterFormat]FrontMatterDecoder
 
 	tip Blocker
 
@@ -25,9 +38,37 @@ type Parser struct {
 	nextNonspaceColumn   int
 	partiallyComsumedTab bool
 	line                 []rune
+	lineStart            Position // where line starts in the input
+
+	// Inline Status
+	inlineText []rune    // the text being parsed into inlines
+	inlineMap  sourceMap // maps inlineText to the source
+}
+
+// An Option configures a Parser.
+type Option func(p *Parser)
+
+// WithMaxSize limits the input to at most n bytes.
+// Larger input makes ParseReader fail with a *SizeError.
+func WithMaxSize(n int) Option {
+	return func(p *Parser) {
+		p.maxSize = n
+	}
+}
+
+// fail records the first error that aborts parsing.
+func (p *Parser) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
 }
 
 func (p *Parser) reset(line []rune) {
+	if p.lineStart.Line == 0 {
+		p.lineStart = Position{Line: 1, Column: 1}
+	} else {
+		p.lineStart = p.lineStart.advance(p.line)
+	}
 	p.line = line
 	p.blank = false
 	p.offset = 0
@@ -35,11 +76,8 @@ func (p *Parser) reset(line []rune) {
 	p.partiallyComsumedTab = false
 }
 
-var doc *Document
-var p *Parser
-
 // column starts from 0, not common 1.
-func findNextNonspace(s []rune) {
+func (p *Parser) findNextNonspace() {
 	offset := p.offset
 	column := p.column
 
@@ -64,12 +102,29 @@ func findNextNonspace(s []rune) {
 	p.indented = p.indentation >= 4
 }
 
-func advanceNextNonspace() {
+// advanceTo moves the offset to s, the rest of the line
+// after the prefixes of containers have been cut off.
+//
+// The offset may be ahead of s if the line has been tried as
+// a start of other blocks, in which case it is moved back.
+func (p *Parser) advanceTo(s []rune) {
+	offset := len(p.line) - len(s)
+	if offset < p.offset {
+		p.offset = 0
+		p.column = 0
+		p.partiallyComsumedTab = false
+	}
+	if n := offset - p.offset; n > 0 {
+		p.advanceOffset(n, false)
+	}
+}
+
+func (p *Parser) advanceNextNonspace() {
 	p.offset = p.nextNonspace
 	p.column = p.nextNonspaceColumn
 }
 
-func advanceOffset(count int, column bool) {
+func (p *Parser) advanceOffset(count int, column bool) {
 	for count > 0 && p.offset < len(p.line) {
 		switch p.line[p.offset] {
 		case '\t':
@@ -118,7 +173,7 @@ func skipIf(s []rune, c rune) []rune {
 	return s
 }
 
-func addLine(pBlocks *[]Blocker, s []rune) bool {
+func (p *Parser) addLine(pBlocks *[]Blocker, s []rune) bool {
 	if len(s) == 0 {
 		return false
 	}
@@ -130,25 +185,33 @@ func addLine(pBlocks *[]Blocker, s []rune) bool {
 	}()
 
 	if len(blocks) > 0 && blocks[len(blocks)-1].AddLine(p, s) {
+		p.extendBlock(blocks[len(blocks)-1], s)
 		return true
 	}
 
 	os := s
 
-	findNextNonspace(s)
+	// appends a new block starting at start.
+	add := func(block Blocker, start []rune) bool {
+		p.startBlock(block, start)
+		blocks = append(blocks, block)
+		return true
+	}
+
+	p.advanceTo(s)
+	p.findNextNonspace()
 
-	if !p.indented {
-		s = s[p.nextNonspace:]
+	// A line of only spaces and tabs is a blank line, however indented.
+	if !p.indented || p.blank {
+		s = p.line[p.nextNonspace:]
 
 		if len(s) == 1 && s[0] == '\n' {
-			blocks = append(blocks, &BlankLine{})
-			return true
+			return add(&BlankLine{}, s)
 		}
 
 		if r, ok := in(s, '-', '_', '*'); ok {
 			if hr := tryParseHorizontalRule(s, r); hr != nil {
-				blocks = append(blocks, hr)
-				return true
+				return add(hr, s)
 			}
 		}
 
@@ -157,25 +220,36 @@ func addLine(pBlocks *[]Blocker, s []rune) bool {
 				// The lines of text must be such that, were they not followed by the setext heading underline, they would be interpreted as a paragraph.
 				// The setext heading underline cannot be a lazy continuation line in a list item or block quote.
 				if pp, ok := p.tip.(*Paragraph); ok && !pp.lazying {
-					blocks = append(blocks, heading)
-					return true
+					return add(heading, s)
+				}
+			}
+		}
+
+		if _, ok := in(s, '|', ':', '-'); ok && p.enabled(ExtTable) {
+			if pp, ok := p.tip.(*Paragraph); ok && !pp.lazying {
+				if table := tryParseTableStart(pp, s); table != nil {
+					return add(table, s)
 				}
 			}
 		}
 
 		if _, ok := in(s, '#'); ok {
-			heading := tryParseAtxHeading(s)
+			heading := p.tryParseAtxHeading(s)
 			if heading != nil {
-				blocks = append(blocks, heading)
-				return true
+				return add(heading, s)
 			}
 		}
 
 		if r, ok := in(s, '`', '~'); ok {
-			cb := tryParseFencedCodeBlockStart(s, r, p.offset)
+			cb := tryParseFencedCodeBlockStart(s, r, p.indentation)
 			if cb != nil {
-				blocks = append(blocks, cb)
-				return true
+				return add(cb, s)
+			}
+		}
+
+		if _, ok := in(s, '$'); ok && p.enabled(ExtMath) {
+			if cb := tryParseMathFence(s, p.indentation); cb != nil {
+				return add(cb, s)
 			}
 		}
 
@@ -186,49 +260,75 @@ func addLine(pBlocks *[]Blocker, s []rune) bool {
 					bq = pbq
 				}
 			}
-			bq, _ = tryParseBlockQuote(s, bq)
+			if bq == nil {
+				bq = &BlockQuote{}
+				p.startBlock(bq, s)
+			}
+			bq, _ = p.tryParseBlockQuote(s, bq)
 			if bq != nil {
 				blocks = append(blocks, bq)
 				return true
 			}
 		}
 
-		if _, ok := in(s, '<'); ok {
-			if hb := tryParseHtmlBlock(os); hb != nil {
-				blocks = append(blocks, hb)
+		if _, ok := in(s, '['); ok && p.enabled(ExtFootnote) {
+			if fd := p.tryParseFootnoteDefinition(s); fd != nil {
+				blocks = append(blocks, fd)
+				return true
+			}
+		}
+
+		if _, ok := in(s, ':'); ok && p.enabled(ExtFencedDiv) {
+			if d := tryParseFencedDivStart(s); d != nil {
+				return add(d, s)
+			}
+		}
+
+		if _, ok := in(s, ':'); ok && p.enabled(ExtDefinitionList) {
+			if p.tryParseDefinitionDescription(&blocks, s) {
 				return true
 			}
 		}
 
+		if _, ok := in(s, '<'); ok {
+			if hb := tryParseHtmlBlock(p, os); hb != nil {
+				return add(hb, s)
+			}
+		}
+
+		if _, ok := in(s, '{'); ok && p.enabled(ExtAttributes) {
+			if pp := p.tryParseAttributeLine(s); pp != nil {
+				return add(pp, s)
+			}
+		}
+
 		_, maybeListMarker := in(s, '-', '+', '*')
-		maybeListStart := '0' <= s[0] && s[0] <= '9'
+		maybeListStart := len(s) > 0 && '0' <= s[0] && s[0] <= '9'
 		if maybeListMarker || maybeListStart {
 			list := &List{}
+			p.startBlock(list, s)
 			if list.AddLine(p, os) {
 				blocks = append(blocks, list)
 				return true
 			}
 		}
 
-		p := &Paragraph{}
-		p.texts = append(p.texts, string(s))
-		blocks = append(blocks, p)
-		return true
-	} else {
-		var cb *CodeBlock
-		if len(blocks) > 0 {
-			if pcb, ok := blocks[len(blocks)-1].(*CodeBlock); ok {
-				cb = pcb
-			}
-		}
-		if cb == nil {
-			cb = &CodeBlock{}
-			blocks = append(blocks, cb)
-		}
-		return cb.AddLine(p, os)
+		pp := &Paragraph{}
+		pp.addLineText(p, s)
+		return add(pp, s)
 	}
 
-	return false
+	var cb *CodeBlock
+	if len(blocks) > 0 {
+		if pcb, ok := blocks[len(blocks)-1].(*CodeBlock); ok {
+			cb = pcb
+		}
+	}
+	if cb == nil {
+		cb = &CodeBlock{}
+		add(cb, os)
+	}
+	return cb.AddLine(p, os)
 }
 
 func isSpace(c rune) bool {
@@ -289,26 +389,138 @@ func any(c rune, rs ...rune) bool {
 	return ok
 }
 
-var ls *LineScanner
+// Parse parses markdown from in into a Document.
+//
+// It is ParseReader with the error discarded, so it never returns nil:
+// what has been read before a failure is parsed and returned,
+// and an empty document is returned if the parser broke.
+//
+// Every call works on its own Parser, so Parse is safe for concurrent use.
+func Parse(in io.Reader, options ...Option) *Document {
+	doc, _ := ParseReader(in, options...)
+	return doc
+}
+
+// ParseReader parses markdown from in into a Document.
+//
+// The document is returned even with an error, and is never nil:
+//
+//   - with a *ReadError if in cannot be read, or a *SizeError if in is
+//     larger than allowed, it is what has been read before,
+//   - with a *FrontMatterError if the front matter cannot be decoded,
+//     it has the raw front matter but no data,
+//   - with the error returned by a Transformer, it is as the transformers
+//     before have left it,
+//   - with an *InternalError if the parser broke, it is empty.
+func ParseReader(in io.Reader, options ...Option) (*Document, error) {
+	p := newParser(options...)
+	doc, err := p.parse(in)
+	if _, ok := err.(*InternalError); ok {
+		return &Document{}, err
+	}
+	return doc, err
+}
+
+func newParser(options ...Option) *Parser {
+	p := &Parser{
+		extensions: DefaultExtensions,
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+func (p *Parser) parse(in io.Reader) (doc *Document, err error) {
+	defer recoverError(&err)
 
-func Parse(in io.Reader) *Document {
 	doc = &Document{}
-	p = &Parser{}
+	doc.links = make(map[string]*LinkReferenceDefinition)
+	doc.footnotes = make(map[string]*FootnoteDefinition)
+	doc.extensions = p.extensions
 	p.doc = doc
 
-	doc.links = make(map[string]*LinkReferenceDefinition)
+	if p.maxSize > 0 {
+		in = &limitedReader{r: in, n: p.maxSize}
+	}
 
-	ls = NewLineScanner(in)
+	ls := NewLineScanner(in)
 
-	for ls.Scan() {
+	if p.enabled(ExtFrontMatter) {
+		doc.frontMatter, err = p.parseFrontMatter(ls)
+	}
+
+	for p.err == nil && ls.Scan() {
 		doc.AddLine(p, ls.Text())
-		tryMergeSetextHeading(&doc.blocks)
+		p.tryMergeSetextHeading(&doc.blocks)
+		p.tryMergeTableHeader(&doc.blocks)
+	}
+
+	if p.err != nil {
+		return doc, p.err
 	}
 
+	// What has been read is still parsed, so that Parse can return it.
+	switch e := ls.Err(); e {
+	case nil:
+	case errTooLarge:
+		err = &SizeError{Limit: p.maxSize}
+	default:
+		err = &ReadError{Err: e}
+	}
+
+	doc.Start = Position{Line: 1, Column: 1}
+	doc.End = doc.Start
+	if p.line != nil {
+		doc.End = p.lineStart.advance(p.line)
+	}
+	finishSpans(doc.blocks)
+
 	doc.parseDefinitions()
-	doc.parseInlines()
+	if p.enabled(ExtDefinitionList) {
+		doc.parseTerms()
+	}
+	if p.enabled(ExtFootnote) {
+		doc.parseFootnotes()
+	}
+	if p.enabled(ExtAttributes) {
+		doc.applyBlockAttributes()
+		doc.parseCodeBlockAttributes()
+	}
+	if p.enabled(ExtMath) {
+		doc.parseMathBlocks()
+	}
+	if p.enabled(ExtAlert) {
+		p.parseAlerts(doc)
+	}
+	if p.enabled(ExtTaskList) {
+		doc.parseTasks()
+	}
+	if p.slugger != nil || p.enabled(ExtAttributes) {
+		doc.parseHeadingIDs(p.enabled(ExtAttributes))
+	}
+	if p.tocMarker != "" {
+		doc.parseTOCs(p.tocMarker, p.tocMinLevel, p.tocMaxLevel)
+	}
+	doc.parseInlines(p)
+	if p.slugger != nil {
+		doc.makeHeadingIDs(p.slugger)
+	}
+	if p.tocMarker != "" {
+		doc.makeTOCs()
+	}
 
-	return doc
+	if p.err != nil {
+		err = p.err
+	} else if e := p.transform(doc); e != nil {
+		err = e
+	}
+
+	// Resolved after the transformers, which may have added paragraphs
+	// to lists, and even with an error, for the document is returned.
+	doc.resolveTightness()
+
+	return doc, err
 }
 
 func tryParseHorizontalRule(c []rune, start rune) *HorizontalRule {
@@ -340,7 +552,7 @@ func tryParseHorizontalRule(c []rune, start rune) *HorizontalRule {
 	return nil
 }
 
-func tryParseAtxHeading(c []rune) *Heading {
+func (p *Parser) tryParseAtxHeading(c []rune) *Heading {
 	i, n := 0, 0
 	for i < len(c) && c[i] == '#' {
 		n++
@@ -416,12 +628,10 @@ func tryParseAtxHeading(c []rune) *Heading {
 
 	// The raw contents of the heading are stripped of
 	// leading and trailing spaces before being parsed as inline content
-	text := string(c[start:end])
-	text = strings.TrimSpace(text)
-
 	return &Heading{
-		Level: n,
-		text:  text,
+		Level:  n,
+		text:   string(c[start:end]),
+		starts: []Position{p.posOf(c[start:])},
 	}
 }
 
@@ -429,10 +639,10 @@ func tryParseSetextHeadingUnderline(c []rune) *SetextHeading {
 	oc := c
 	start := c[0]
 	i := 0
-	for c[i] == start {
+	for i < len(c) && c[i] == start {
 		i++
 	}
-	for c[i] == ' ' {
+	for i < len(c) && c[i] == ' ' {
 		i++
 	}
 	if i == len(c) || c[i] == '\n' {
@@ -451,7 +661,7 @@ func tryParseSetextHeadingUnderline(c []rune) *SetextHeading {
 func tryParseFencedCodeBlockStart(c []rune, marker rune, indent int) *CodeBlock {
 	cb := &CodeBlock{}
 	cb.fenceIndent = indent
-	cb.fenceMarker = marker
+	cb.FenceMarker = marker
 
 	i := 0
 
@@ -465,7 +675,7 @@ func tryParseFencedCodeBlockStart(c []rune, marker rune, indent int) *CodeBlock
 		return nil
 	}
 
-	cb.fenceLength = i
+	cb.FenceLength = i
 
 	// The line with the opening code fence may optionally
 	// contain some text following the code fence;
@@ -592,7 +802,7 @@ exit:
 	}
 }
 
-func tryParseBlockQuote(s []rune, bq *BlockQuote) (*BlockQuote, bool) {
+func (p *Parser) tryParseBlockQuote(s []rune, bq *BlockQuote) (*BlockQuote, bool) {
 	_, s = skipPrefixSpaces(s, 3)
 
 	if len(s) == 0 || s[0] != '>' {
@@ -608,26 +818,51 @@ func tryParseBlockQuote(s []rune, bq *BlockQuote) (*BlockQuote, bool) {
 	if bq == nil {
 		bq = &BlockQuote{}
 	}
-	return bq, addLine(&bq.blocks, s)
+	// The rest of the line is not a paragraph continuation line.
+	p.tip = bq
+	return bq, p.addLine(&bq.blocks, s)
 }
 
-func parseInlinesToDeimiters(raw string) (*list.List, *list.List) {
-	c := []rune(raw)
+func (p *Parser) parseInlinesToDeimiters(c []rune) (*list.List, *list.List) {
 	texts := list.New()
 	text := []rune{}
+	textStart := 0
 	delimiters := list.New()
 
-	appendDelimiter := func(s string) {
+	// where c[i] is in the whole text.
+	at := func(i int) int {
+		return len(p.inlineText) - len(c) + i
+	}
+
+	// span sets the span of inline to the text from start to end, both in the whole text.
+	span := func(inline Inline, start, end int) Inline {
+		*spanOf(inline) = p.inlineMap.span(start, end)
+		return inline
+	}
+
+	flush := func(end int) {
 		if len(text) > 0 {
-			texts.PushFront(&Text{
+			texts.PushFront(span(&Text{
 				Text: string(text),
-			})
+			}, textStart, end))
 			text = text[:0]
 		}
+	}
+
+	appendRune := func(r rune, i int) {
+		if len(text) == 0 {
+			textStart = at(i)
+		}
+		text = append(text, r)
+	}
+
+	appendDelimiter := func(s string, i int) {
+		flush(at(i))
 		if s != "" {
 			t := &Text{
 				Text: s,
 			}
+			span(t, at(i), at(i)+len(s))
 			te := texts.PushFront(t)
 			d := &Delimiter{
 				textElement: te,
@@ -638,15 +873,10 @@ func parseInlinesToDeimiters(raw string) (*list.List, *list.List) {
 		}
 	}
 
-	appendText := func(inline Inline) {
-		if len(text) > 0 {
-			texts.PushFront(&Text{
-				Text: string(text),
-			})
-			text = text[:0]
-		}
+	appendText := func(inline Inline, start, end int) {
+		flush(start)
 		if inline != nil {
-			texts.PushFront(inline)
+			texts.PushFront(span(inline, start, end))
 		}
 	}
 
@@ -659,69 +889,131 @@ func parseInlinesToDeimiters(raw string) (*list.List, *list.List) {
 			for end < len(c) && c[end] == ch {
 				end++
 			}
-			appendDelimiter(string(c[start:end]))
+			appendDelimiter(string(c[start:end]), i)
+			i = end
+		case '~':
+			end := i
+			for end < len(c) && c[end] == ch {
+				end++
+			}
+			switch {
+			case !p.enabled(ExtStrikethrough):
+				appendText(&Text{
+					Text: string(c[i:end]),
+				}, at(i), at(end))
+			case end-i <= 2:
+				appendDelimiter(string(c[i:end]), i)
+			default:
+				// Three or more tildes do not make strikethrough.
+				appendText(&Text{
+					Text: string(c[i:end]),
+				}, at(i), at(end))
+			}
 			i = end
 		case '!':
-			i++
-			if i < len(c) && c[i] == '[' {
-				appendDelimiter("![")
-				i++
+			if i+1 < len(c) && c[i+1] == '[' {
+				appendDelimiter("![", i)
+				i += 2
 			} else {
-				text = append(text, '!')
+				appendRune('!', i)
+				i++
 			}
 		case '[':
-			appendDelimiter("[")
+			if p.enabled(ExtFootnote) {
+				if n, label := parseFootnoteLabel(c[i:]); n > 0 {
+					if ref := p.doc.refFootnote(label); ref != nil {
+						appendText(ref, at(i), at(i+n))
+						i += n
+						continue
+					}
+				}
+			}
+			appendDelimiter("[", i)
 			i++
 		case ']':
-			appendDelimiter("]")
+			appendDelimiter("]", i)
 			i++
-			if nc, ok := parseRightBracket(texts, delimiters, c[i:]); ok {
+			if nc, ok := p.parseRightBracket(texts, delimiters, c[i:]); ok {
 				c = nc
 				i = 0
 			}
 		case '\\':
 			if j := i + 1; j < len(c) {
 				if isPunctuation(c[j]) {
-					text = append(text, c[j])
+					appendRune(c[j], i)
 					i++
 					i++
 					continue
 				} else if c[j] == '\n' {
 					// A backslash at the end of the line is a hard line break
-					appendText(&HardLineBreak{})
+					appendText(&HardLineBreak{}, at(i), at(i+2))
 					i++
 					i++
 					continue
 				}
 			}
-			text = append(text, '\\')
+			appendRune('\\', i)
 			i++
 		case '<':
+			start := at(i)
 			if nc, link, ok := parseUriAutoLink(c[i:]); ok {
 				c = nc
 				i = 0
-				appendText(link)
+				appendText(link, start, at(i))
+				span(link.Inlines[0], start+1, at(i)-1)
 				continue
 			}
 			if nc, tag := tryParseHtmlTag(c[i:]); tag != nil {
 				i = 0
 				c = nc
-				appendText(tag)
+				appendText(tag, start, at(i))
 				continue
 			}
 			if nc, link := parseEmailAutoLink(c[i:]); link != nil {
 				c = nc
 				i = 0
-				appendText(link)
+				appendText(link, start, at(i))
+				span(link.Inlines[0], start+1, at(i)-1)
 				continue
 			}
-			text = append(text, '<')
+			appendRune('<', i)
+			i++
+		case '$':
+			start := at(i)
+			if p.enabled(ExtMath) {
+				if nc, math := tryParseMath(c[i:]); math != nil {
+					i = 0
+					c = nc
+					appendText(math, start, at(i))
+					continue
+				}
+			}
+			j := i
+			for j < len(c) && c[j] == '$' {
+				j++
+			}
+			appendText(&Text{
+				Text: string(c[i:j]),
+			}, start, at(j))
+			i = j
+		case ':':
+			start := at(i)
+			if p.enabled(ExtEmoji) && (start == 0 || !isAlNum(p.inlineText[start-1])) {
+				if nc, emoji := tryParseEmoji(c[i:]); emoji != nil {
+					i = 0
+					c = nc
+					appendText(emoji, start, at(i))
+					continue
+				}
+			}
+			appendRune(':', i)
 			i++
 		case '`':
-			if nc, span := tryParseCodeSpan(c[i:]); span != nil {
+			start := at(i)
+			if nc, cs := tryParseCodeSpan(c[i:]); cs != nil {
 				i = 0
 				c = nc
-				appendText(span)
+				appendText(cs, start, at(i))
 				continue
 			}
 			j := i
@@ -730,9 +1022,10 @@ func parseInlinesToDeimiters(raw string) (*list.List, *list.List) {
 			}
 			appendText(&Text{
 				Text: strings.Repeat("`", j-i),
-			})
+			}, start, at(j))
 			i = j
 		case '&':
+			start := at(i)
 			if nc, cp1, cp2, ok := tryParseHtmlEntity(c[i:]); ok {
 				i = 0
 				c = nc
@@ -745,78 +1038,78 @@ func parseInlinesToDeimiters(raw string) (*list.List, *list.List) {
 				}
 				appendText(&Text{
 					Text: string(r),
-				})
+				}, start, at(i))
 				continue
 			}
 			appendText(&Text{
 				Text: "&",
-			})
+			}, start, at(i+1))
 			i++
 		case '\n':
-			appendText(&Text{
-				Text: "\n",
-			})
+			// A line ending, rather than one from a character reference.
+			appendText(&SoftLineBreak{}, at(i), at(i+1))
 			i++
 		default:
-			text = append(text, ch)
+			appendRune(ch, i)
 			i++
 		}
 	}
 
-	appendDelimiter("")
+	appendDelimiter("", i)
 
 	return texts, delimiters
 }
 
-func parseInlines(raw string) (inlines []Inline) {
-	texts, delimiters := parseInlinesToDeimiters(raw)
+func (p *Parser) parseInlines(raw string, starts []Position) (inlines []Inline) {
+	text := []rune(raw)
+	m := newSourceMap(text, starts)
+
+	// The raw contents are stripped of leading and trailing spaces before being parsed as inline content.
+	p.inlineText, p.inlineMap = m.trimSpace(text)
+
+	texts, delimiters := p.parseInlinesToDeimiters(p.inlineText)
 	parseLineBreaks(texts)
 	parseEmphases(texts, delimiters, nil)
 	for e := texts.Back(); e != nil; e = e.Prev() {
-		inlines = append(inlines, e.Value)
+		inlines = append(inlines, e.Value.(Inline))
+	}
+	if p.enabled(ExtAutolink) {
+		inlines = parseExtendedAutolinks(inlines)
+	}
+	if p.enabled(ExtAttributes) {
+		inlines = parseInlineAttributes(inlines)
 	}
 	return
 }
 
+// parseLineBreaks removes spaces at the end of each line, and makes the line
+// endings after two or more of them hard line breaks.
 func parseLineBreaks(texts *list.List) {
-	if texts.Len() < 3 {
-		return
-	}
-
-	hasTwoSpaces := func(s string) bool {
-		n := len(s)
-		return n >= 2 && s[n-2] == ' ' && s[n-1] == ' '
-	}
-
-	current := texts.Back().Prev()
-	last := texts.Front()
-
-	for current != last {
-		prevText, ok2 := current.Next().Value.(*Text)
-		currText, ok1 := current.Value.(*Text)
-		if ok1 && ok2 {
-			if currText.Text == "\n" {
-				if hasTwoSpaces(prevText.Text) {
-					hard := &HardLineBreak{}
-					texts.InsertAfter(hard, current)
-				} else {
-					soft := &SoftLineBreak{}
-					texts.InsertAfter(soft, current)
-				}
-				// Spaces at the end of the line and beginning of the next line are removed
-				// beginnings are remove while adding line to paragraph.
-				prevText.Text = strings.TrimRight(prevText.Text, " ")
-				next := current.Prev()
-				texts.Remove(current)
-				current = next
-				continue
-			}
+	for e := texts.Back(); e != nil; e = e.Prev() {
+		soft, ok := e.Value.(*SoftLineBreak)
+		if !ok || e.Next() == nil {
+			continue
+		}
+		prevText, ok := e.Next().Value.(*Text)
+		if !ok {
+			continue
+		}
+		// Spaces at the beginning of the next line are removed
+		// while adding the line to the paragraph.
+		trimmed := strings.TrimRight(prevText.Text, " ")
+		n := len(prevText.Text) - len(trimmed)
+		prevText.Text = trimmed
+		prevText.End.Column -= n
+		prevText.End.Offset -= n
+		if n >= 2 {
+			hard := &HardLineBreak{}
+			hard.Span = Span{Start: prevText.End, End: soft.End}
+			e.Value = hard
 		}
-		current = current.Prev()
 	}
 }
 
-func parseRightBracket(texts *list.List, delimiters *list.List, c []rune) ([]rune, bool) {
+func (p *Parser) parseRightBracket(texts *list.List, delimiters *list.List, c []rune) ([]rune, bool) {
 	toDelimiter := func(v *list.Element) *Delimiter {
 		return v.Value.(*Delimiter)
 	}
@@ -857,25 +1150,30 @@ func parseRightBracket(texts *list.List, delimiters *list.List, c []rune) ([]run
 		return nil, false
 	}
 
+	style := InlineLink
+
 	// hack, to get texts betwee "[" and "]", for ref.
 	// TODO remember positions of "[" and "]".
 	if hasRef {
+		switch ref {
+		case "":
+			style = ShortcutReferenceLink
+		case "[]":
+			style = CollapsedReferenceLink
+		default:
+			style = FullReferenceLink
+		}
 		if ref == "" || ref == "[]" {
 			ref = ""
 			for e := opener.textElement; e != nil; {
-				tc, ok := e.Value.(ITextContent)
-				if !ok {
-					panic("implement ITextContnet")
-				}
-				ref += tc.TextContent()
+				ref += textContent(e.Value)
 				e = e.Prev()
 			}
 		}
-		ref = strings.ToLower(ref)
 		if ref[0] == '!' {
 			ref = ref[1:]
 		}
-		dest, tt, ok := doc.refLink(ref, true)
+		dest, tt, ok := p.doc.refLink(strings.ToLower(ref), true)
 		if !ok {
 			delimiters.Remove(openerElement)
 			return nil, false
@@ -885,12 +1183,28 @@ func parseRightBracket(texts *list.List, delimiters *list.List, c []rune) ([]run
 		title = tt
 	}
 
+	// from "[" or "![" to the end of the link.
+	span := Span{
+		Start: spanOf(opener.textElement.Value).Start,
+		End:   p.inlineMap[len(p.inlineText)-len(nc)],
+	}
+
+	if !hasRef {
+		ref = ""
+	}
+
 	if opener.text == "[" {
+		link.Span = span
 		link.Link = destination
 		link.Title = title
+		link.Style = style
+		link.Label = ref
 	} else {
+		image.Span = span
 		image.Link = destination
 		image.Title = title
+		image.Style = style
+		image.Label = ref
 	}
 
 	c = nc
@@ -903,7 +1217,7 @@ func parseRightBracket(texts *list.List, delimiters *list.List, c []rune) ([]run
 	if opener.text == "[" {
 		parseEmphases(texts, delimiters, openerElement)
 		for e := opener.textElement.Prev(); e != nil; {
-			link.Inlines = append(link.Inlines, e.Value)
+			link.Inlines = append(link.Inlines, e.Value.(Inline))
 			e = e.Prev()
 		}
 		// opener is about to be removed, hence we insert it after (at left) opener.
@@ -911,7 +1225,7 @@ func parseRightBracket(texts *list.List, delimiters *list.List, c []rune) ([]run
 	} else {
 		parseEmphases(texts, delimiters, openerElement)
 		for e := opener.textElement.Prev(); e != nil; {
-			image.inlines = append(image.inlines, e.Value)
+			image.Inlines = append(image.Inlines, e.Value.(Inline))
 			if tc, ok := e.Value.(ITextContent); ok {
 				image.Alt += tc.TextContent()
 			}
@@ -1008,18 +1322,28 @@ func parseEmphases(texts *list.List, delimiters *list.List, bottom *list.Element
 			n = 2
 		}
 
-		// Insert an emph or strong emph node accordingly, after the text node corresponding to the opener.
-		emphasis := &Emphasis{
-			Delimiter: string(opener.text[0:n]),
-		}
-
 		// texts between opener and closer are contents of emphasis.
+		var inlines []Inline
 		for e := opener.textElement.Prev(); e != nil && e != closer.textElement; {
-			emphasis.Inlines = append(emphasis.Inlines, e.Value)
+			inlines = append(inlines, e.Value.(Inline))
 			next := e.Prev()
 			texts.Remove(e)
 			e = next
 		}
+
+		// Insert an emph or strong emph node accordingly, after the text node corresponding to the opener.
+		var emphasis Inline
+		if opener.text[0] == '~' {
+			emphasis = &Strikethrough{
+				Delimiter: opener.text[0:n],
+				Inlines:   inlines,
+			}
+		} else {
+			emphasis = &Emphasis{
+				Delimiter: opener.text[0:n],
+				Inlines:   inlines,
+			}
+		}
 		texts.InsertBefore(emphasis, opener.textElement)
 
 		// Remove any delimiters between the opener and closer from the delimiter stack.
@@ -1032,8 +1356,12 @@ func parseEmphases(texts *list.List, delimiters *list.List, bottom *list.Element
 		// Remove 1 (for regular emph) or 2 (for strong emph) delimiters from the opening and closing text nodes.
 		// If they become empty as a result, remove them and remove the corresponding element of the delimiter stack.
 		// If the closing node is removed, reset current_position to the next element in the stack.
-		openerEmpty := opener.consume(n) == 0
-		closerEmpty := closer.consume(n) == 0
+		openerEmpty := opener.consume(n, false) == 0
+		closerEmpty := closer.consume(n, true) == 0
+		*spanOf(emphasis) = Span{
+			Start: spanOf(opener.textElement.Value).End,
+			End:   spanOf(closer.textElement.Value).Start,
+		}
 		if openerEmpty {
 			delimiters.Remove(openerElement)
 			texts.Remove(opener.textElement)
@@ -1105,7 +1433,14 @@ func parseLink(c []rune) (remain []rune, dest string, title string, ref string,
 	i++ // skip '('
 
 	skipWhitespaces(0)
-	if i == len(c) || c[i] == ')' {
+	if i == len(c) {
+		// not closed, try as a shortcut link ref
+		remain = oc
+		hasRef = true
+		oook = true
+		return
+	}
+	if c[i] == ')' {
 		i++
 		remain = c[i:]
 		oook = true
@@ -1122,7 +1457,14 @@ func parseLink(c []rune) (remain []rune, dest string, title string, ref string,
 	i = 0
 
 	skipWhitespaces(0)
-	if i == len(c) || c[i] == ')' {
+	if i == len(c) {
+		// not closed, try as a shortcut link ref
+		remain = oc
+		hasRef = true
+		oook = true
+		return
+	}
+	if c[i] == ')' {
 		i++
 		remain = c[i:]
 		oook = true
@@ -1448,6 +1790,7 @@ func parseUriAutoLink(c []rune) ([]rune, *Link, bool) {
 				Text: full,
 			},
 		},
+		Style: Autolink,
 	}
 	return c[i+1:], link, true
 }
@@ -1466,6 +1809,7 @@ func parseEmailAutoLink(c []rune) ([]rune, *Link) {
 	link := Link{
 		Inlines: []Inline{&Text{Text: email}},
 		Link:    "mailto:" + email,
+		Style:   Autolink,
 	}
 	remain := []rune(string(bys[loc[1]:]))
 	return remain, &link
@@ -1921,7 +2265,7 @@ func tryParseHtmlTag(c []rune) ([]rune, *HtmlTag) {
 	}
 }
 
-func tryParseHtmlBlock(c []rune) *HtmlBlock {
+func tryParseHtmlBlock(p *Parser, c []rune) *HtmlBlock {
 	i := 0
 	for i < len(c) && isWahitespace(c[i]) {
 		i++
@@ -2166,9 +2510,7 @@ func tryParseHtmlBlock(c []rune) *HtmlBlock {
 		}
 
 		return nil
-
 	}
-	return nil
 }
 
 // tryParseLinkReferenceDefinition parses link reference definition from a paragraph.
@@ -2263,7 +2605,7 @@ func tryParseLinkReferenceDefinition(c []rune) ([]rune, *LinkReferenceDefinition
 	return beforeTitle, &l
 }
 
-func tryMergeSetextHeading(pbs *[]Blocker) {
+func (p *Parser) tryMergeSetextHeading(pbs *[]Blocker) {
 	n := len(*pbs)
 	if n < 1 {
 		return
@@ -2275,17 +2617,18 @@ func tryMergeSetextHeading(pbs *[]Blocker) {
 	switch typed := blocks[n-1].(type) {
 	case *SetextHeading:
 		if n == 1 {
-			blocks[n-1] = &Paragraph{
-				texts: []string{string(typed.line)},
-			}
+			blocks[n-1] = typed.toParagraph()
 			return
 		}
-		if p, ok := blocks[n-2].(*Paragraph); ok {
-			p.parseDefinitions()
-			if len(p.texts) > 0 && len(p.texts[0]) > 0 {
+		if pp, ok := blocks[n-2].(*Paragraph); ok {
+			pp.parseDefinitions(p.doc)
+			if len(pp.texts) > 0 && len(pp.texts[0]) > 0 && pp.nextAttributes == nil {
 				heading := Heading{
-					Level: typed.level,
-					text:  strings.Join(p.texts, ""),
+					Span:   Span{Start: pp.Start, End: typed.End},
+					Level:  typed.level,
+					text:   strings.Join(pp.texts, ""),
+					starts: pp.starts,
+					Setext: true,
 				}
 				blocks[n-2] = &heading
 				blocks = blocks[:n-1]
@@ -2297,12 +2640,15 @@ func tryMergeSetextHeading(pbs *[]Blocker) {
 			// The setext heading underline cannot contain internal spaces
 			heading := tryParseSetextHeadingUnderline(typed.s)
 			if heading != nil {
-				if p, ok := blocks[n-2].(*Paragraph); ok {
-					p.parseDefinitions()
-					if len(p.texts) > 0 && len(p.texts[0]) > 0 {
+				if pp, ok := blocks[n-2].(*Paragraph); ok {
+					pp.parseDefinitions(p.doc)
+					if len(pp.texts) > 0 && len(pp.texts[0]) > 0 && pp.nextAttributes == nil {
 						heading := Heading{
-							Level: 2,
-							text:  strings.Join(p.texts, ""),
+							Span:   Span{Start: pp.Start, End: typed.End},
+							Level:  2,
+							text:   strings.Join(pp.texts, ""),
+							starts: pp.starts,
+							Setext: true,
 						}
 						blocks[n-2] = &heading
 						blocks = blocks[:n-1]
@@ -2314,8 +2660,6 @@ func tryMergeSetextHeading(pbs *[]Blocker) {
 	}
 	switch typed := blocks[n-1].(type) {
 	case *SetextHeading:
-		blocks[n-1] = &Paragraph{
-			texts: []string{string(typed.line)},
-		}
+		blocks[n-1] = typed.toParagraph()
 	}
 }
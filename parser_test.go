@@ -0,0 +1,95 @@
+package taomd
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// allOptions enables all extensions, heading IDs and tables of contents.
+var allOptions = []Option{
+	WithExtensions(GFM | ExtFootnote | ExtAttributes | ExtMath | ExtEmoji |
+		ExtDefinitionList | ExtAlert | ExtFencedDiv),
+	WithHeadingIDs(nil),
+	WithTOC("", 2, 3),
+}
+
+// TestConcurrency parses and renders the spec from several goroutines
+// at once, which has to be run with -race to be of much use.
+func TestConcurrency(t *testing.T) {
+	examples := loadExamples(t, "testdata/spec.json")
+
+	want := make([]string, len(examples))
+	for i, example := range examples {
+		want[i] = Render(Parse(strings.NewReader(example.Markdown), allOptions...))
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, example := range examples {
+				doc := Parse(strings.NewReader(example.Markdown), allOptions...)
+				if html := Render(doc); html != want[i] {
+					t.Errorf("example %d: want:\n%s\ngiven:\n%s", example.Example, want[i], html)
+				}
+				RenderMarkdown(doc)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestConcurrentRender renders the same parsed documents from several
+// goroutines at once, as when they are cached and shared, and compares
+// the results with those rendered one by one.
+func TestConcurrentRender(t *testing.T) {
+	examples := loadExamples(t, "testdata/spec.json")
+	docs := make([]*Document, len(examples))
+	html := make([]string, len(examples))
+	md := make([]string, len(examples))
+	for i, example := range examples {
+		docs[i] = Parse(strings.NewReader(example.Markdown), allOptions...)
+		html[i] = Render(docs[i])
+		md[i] = RenderMarkdown(docs[i])
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, doc := range docs {
+				if got := Render(doc); got != html[i] {
+					t.Errorf("example %d: want:\n%s\ngiven:\n%s", examples[i].Example, html[i], got)
+				}
+				if got := RenderMarkdown(doc); got != md[i] {
+					t.Errorf("example %d: want:\n%s\ngiven:\n%s", examples[i].Example, md[i], got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// loadCorpus loads the markdown of all spec examples.
+func loadCorpus(b *testing.B) (corpus []string, size int64) {
+	for _, example := range loadExamples(b, "testdata/spec.json") {
+		corpus = append(corpus, example.Markdown)
+		size += int64(len(example.Markdown))
+	}
+	return corpus, size
+}
+
+func BenchmarkParse(b *testing.B) {
+	corpus, size := loadCorpus(b)
+	b.ReportAllocs()
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, md := range corpus {
+			Parse(strings.NewReader(md))
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package taomd
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A Position is a location in the markdown source.
+type Position struct {
+	// Line is the line number, starting from 1.
+	Line int
+
+	// Column is the character (not byte) count from the start of the line, starting from 1.
+	// A tab counts as one character.
+	Column int
+
+	// Offset is the byte offset from the start of the input, starting from 0.
+	// Invalid UTF-8 bytes count as the replacement character.
+	Offset int
+}
+
+// advance returns the position after s.
+func (pos Position) advance(s []rune) Position {
+	for _, r := range s {
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+			pos.Offset++
+			continue
+		}
+		pos.Column++
+		pos.Offset += utf8.RuneLen(r)
+	}
+	return pos
+}
+
+// A Span is the part of the markdown source a node comes from.
+// Start is the position of its first character, End is the position
+// right after its last character, not including the line ending.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Pos returns where the node is in the markdown source.
+func (s *Span) Pos() Span {
+	return *s
+}
+
+func (s *Span) span() *Span {
+	return s
+}
+
+// spanner is implemented by all nodes by embedding a Span.
+type spanner interface {
+	span() *Span
+}
+
+// spanOf returns the span of node to be changed, or a dummy if node has none.
+func spanOf(node interface{}) *Span {
+	if sp, ok := node.(spanner); ok {
+		return sp.span()
+	}
+	return &Span{}
+}
+
+// posOf returns the position of s, which must be a suffix of the current line.
+func (p *Parser) posOf(s []rune) Position {
+	i := len(p.line) - len(s)
+	return p.lineStart.advance(p.line[:i])
+}
+
+// lineEnd returns the position of the end of the current line, before the line ending.
+func (p *Parser) lineEnd() Position {
+	line := p.line
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+	return p.lineStart.advance(line)
+}
+
+// startBlock sets where the block starts, at s of the current line.
+func (p *Parser) startBlock(block Blocker, s []rune) {
+	sp := spanOf(block)
+	sp.Start = p.posOf(s)
+	sp.End = p.lineEnd()
+}
+
+// extendBlock extends the block to the end of the current line
+// if s, the part of the line given to the block, is not blank.
+func (p *Parser) extendBlock(block Blocker, s []rune) {
+	if !isBlankLine(s) {
+		spanOf(block).End = p.lineEnd()
+	}
+}
+
+// finishSpans makes containers end no earlier than their last children,
+// which may have been extended by lazy continuation lines.
+// Blank lines don't count as the end of their containers.
+func finishSpans(blocks []Blocker) Position {
+	var end Position
+	for _, block := range blocks {
+		if _, ok := block.(*BlankLine); ok {
+			continue
+		}
+		var children []Blocker
+		switch typed := block.(type) {
+		case *BlockQuote:
+			children = typed.blocks
+		case *List:
+			children = typed.Items
+		case *ListItem:
+			children = typed.blocks
+		case *FootnoteDefinition:
+			children = typed.blocks
+		case *DefinitionList:
+			children = typed.Items
+		case *DefinitionDescription:
+			children = typed.blocks
+		case *FencedDiv:
+			children = typed.blocks
+		}
+		sp := spanOf(block)
+		if childEnd := finishSpans(children); childEnd.Offset > sp.End.Offset {
+			sp.End = childEnd
+		}
+		if sp.End.Offset > end.Offset {
+			end = sp.End
+		}
+	}
+	return end
+}
+
+// A sourceMap holds the source position of each character of a text
+// joined from lines, plus the position right after the text.
+type sourceMap []Position
+
+// newSourceMap maps text joined from lines to the source.
+// starts are the positions of the lines; the n-th line starts after the n-th '\n' of text.
+func newSourceMap(text []rune, starts []Position) sourceMap {
+	m := make(sourceMap, len(text)+1)
+	var pos Position
+	if len(starts) > 0 {
+		pos = starts[0]
+	}
+	line := 0
+	for i, r := range text {
+		m[i] = pos
+		pos = pos.advance(text[i : i+1])
+		if r == '\n' {
+			line++
+			if line < len(starts) {
+				pos = starts[line]
+			}
+		}
+	}
+	m[len(text)] = pos
+	return m
+}
+
+// skip returns the line starts of text[n:], as mapped by m.
+func (m sourceMap) skip(text []rune, starts []Position, n int) []Position {
+	line := 0
+	for _, r := range text[:n] {
+		if r == '\n' {
+			line++
+		}
+	}
+	remain := []Position{m[n]}
+	if line+1 < len(starts) {
+		remain = append(remain, starts[line+1:]...)
+	}
+	return remain
+}
+
+// span returns the span of text[start:end].
+func (m sourceMap) span(start, end int) Span {
+	return Span{Start: m[start], End: m[end]}
+}
+
+// trimSpace trims leading and trailing whitespace characters of text,
+// and returns the trimmed text with its mapping.
+//
+// Other Unicode spaces, such as no-break spaces, are not trimmed.
+func (m sourceMap) trimSpace(text []rune) ([]rune, sourceMap) {
+	isSpace := func(r rune) bool {
+		return strings.ContainsRune(" \t\n\v\f\r", r)
+	}
+	i, j := 0, len(text)
+	for i < j && isSpace(text[i]) {
+		i++
+	}
+	for j > i && isSpace(text[j-1]) {
+		j--
+	}
+	return text[i:j], m[i : j+1]
+}
@@ -0,0 +1,77 @@
+package taomd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// positions lists the kinds and spans of the nodes of doc in document order,
+// as "Kind line:column-line:column+offset-offset", leaving out blank lines.
+func positions(doc *Document) []string {
+	var list []string
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		if _, ok := n.(*BlankLine); ok || !entering {
+			return WalkContinue
+		}
+		p := n.Pos()
+		list = append(list, fmt.Sprintf("%s %d:%d-%d:%d+%d-%d", n.Kind(),
+			p.Start.Line, p.Start.Column, p.End.Line, p.End.Column, p.Start.Offset, p.End.Offset))
+		return WalkContinue
+	})
+	return list
+}
+
+func TestPositions(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     []string
+	}{
+		{
+			"# Head\n\n> a *b*\n> c\n",
+			[]string{
+				"Document 1:1-5:1+0-20",
+				"Heading 1:1-1:7+0-6",
+				"Text 1:3-1:7+2-6",
+				"BlockQuote 3:1-4:4+8-19",
+				"Paragraph 3:3-4:4+10-19",
+				"Text 3:3-3:5+10-12",
+				"Emphasis 3:5-3:8+12-15",
+				"Text 3:6-3:7+13-14",
+				"SoftLineBreak 3:8-4:3+15-18",
+				"Text 4:3-4:4+18-19",
+			},
+		},
+		{
+			"- x\n  ```\n  y\n  ```\n",
+			[]string{
+				"Document 1:1-5:1+0-20",
+				"List 1:1-4:6+0-19",
+				"ListItem 1:1-4:6+0-19",
+				"Paragraph 1:3-1:4+2-3",
+				"Text 1:3-1:4+2-3",
+				"CodeBlock 2:3-4:6+6-19",
+			},
+		},
+		{
+			// Columns count characters, and offsets count bytes.
+			"[l](/u) 你好 `z`",
+			[]string{
+				"Document 1:1-1:15+0-18",
+				"Paragraph 1:1-1:15+0-18",
+				"Link 1:1-1:8+0-7",
+				"Text 1:2-1:3+1-2",
+				"Text 1:8-1:12+7-15",
+				"CodeSpan 1:12-1:15+15-18",
+			},
+		},
+	}
+	for _, test := range tests {
+		doc := Parse(strings.NewReader(test.markdown))
+		if got := positions(doc); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q:\nwant:\n%s\ngiven:\n%s", test.markdown,
+				strings.Join(test.want, "\n"), strings.Join(got, "\n"))
+		}
+	}
+}
@@ -1,200 +1,769 @@
 package taomd
 
 import (
+	"bufio"
 	"fmt"
-	"reflect"
+	"io"
+	"strconv"
 	"strings"
 )
 
+// Render renders doc as HTML.
+//
+// Errors are discarded and what has been rendered so far is returned.
+// Use RenderTo to see them.
+//
+// Rendering never changes doc, so the same document can be rendered
+// any number of times, concurrently.
 func Render(doc *Document) string {
-	var s string
+	return defaultRenderer.Render(doc)
+}
+
+// RenderTo renders doc as HTML into w.
+//
+// The output is buffered and streamed into w while doc is being rendered,
+// so what has been rendered is written even if an error occurs.
+//
+// It returns an *InternalError if doc contains nodes it cannot render,
+// or the error returned by w.
+func RenderTo(w io.Writer, doc *Document) error {
+	return defaultRenderer.RenderTo(w, doc)
+}
+
+// defaultRenderer renders for Render, RenderTo and RenderTOC.
+// It is never changed after it is made, with Register or otherwise,
+// so that it can be used by any number of goroutines at once.
+var defaultRenderer = NewRenderer()
 
-	for _, block := range doc.blocks {
-		s += toHTML(block)
+// RenderTOC renders a table of contents, as returned by Document.TOC,
+// as nested lists of links to the headings.
+func RenderTOC(toc []*TOCEntry) string {
+	var sb strings.Builder
+	w := &Writer{
+		Writer:   bufio.NewWriter(&sb),
+		renderer: defaultRenderer,
 	}
+	renderTOC(w, toc)
+	w.Flush()
+	return sb.String()
+}
+
+// A RenderFunc renders node into w. Like a Walker, it is called with
+// entering set to true before the children of node are rendered, and
+// with entering set to false after them.
+//
+// Returning WalkSkipChildren leaves the children unrendered, which is
+// how a RenderFunc can render them on its own, by w.Render.
+type RenderFunc func(w *Writer, node Node, entering bool) (WalkStatus, error)
+
+// A Renderer renders documents as HTML.
+//
+// By default, every kind of nodes is rendered as CommonMark does.
+// The rendering of a kind of nodes can be replaced by Register,
+// and that of fenced divs of a name by RegisterFencedDiv.
+type Renderer struct {
+	funcs    map[Kind]RenderFunc
+	divFuncs map[string]RenderFunc // by the names of fenced divs
 
-	return s
+	emojiURL string // see WithEmojiImages
+
+	unsafeAttributes bool // see WithUnsafeAttributes
 }
 
-func escapeText(s string) string {
-	return strings.NewReplacer(
-		`&`, "&amp;",
-		`"`, "&quot;",
-		`<`, "&lt;",
-		`>`, "&gt;",
-	).Replace(s)
+// A RendererOption configures a Renderer.
+type RendererOption func(r *Renderer)
+
+// WithRenderFunc renders nodes of kind by fn.
+func WithRenderFunc(kind Kind, fn RenderFunc) RendererOption {
+	return func(r *Renderer) {
+		r.Register(kind, fn)
+	}
 }
 
-func toInline(inline Inline) string {
-	s := ""
-	switch it := inline.(type) {
-	default:
-		panic("unhandled inline: " + reflect.TypeOf(it).String())
-	case *Text:
-		s += escapeText(it.Text)
-	case *Link:
-		s += fmt.Sprintf(`<a href="%s"`, escapeText(urlEncode(it.Link)))
-		if it.Title != "" {
-			s += fmt.Sprintf(` title="%s"`, escapeText(it.Title))
+// WithUnsafeAttributes writes event handler attributes of attribute lists,
+// such as onclick, which are left out by default, for they run scripts.
+// Use it only if the markdown is trusted.
+func WithUnsafeAttributes() RendererOption {
+	return func(r *Renderer) {
+		r.unsafeAttributes = true
+	}
+}
+
+// NewRenderer news a Renderer.
+func NewRenderer(options ...RendererOption) *Renderer {
+	r := &Renderer{
+		funcs:    make(map[Kind]RenderFunc),
+		divFuncs: make(map[string]RenderFunc),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Register renders nodes of kind by fn, instead of by default.
+// A nil fn restores the default.
+//
+// Register must not be called while the Renderer is rendering.
+func (r *Renderer) Register(kind Kind, fn RenderFunc) {
+	if fn == nil {
+		delete(r.funcs, kind)
+		return
+	}
+	r.funcs[kind] = fn
+}
+
+// Render renders doc as HTML, like the package level Render does.
+func (r *Renderer) Render(doc *Document) string {
+	var sb strings.Builder
+	r.RenderTo(&sb, doc)
+	return sb.String()
+}
+
+// RenderTo renders doc as HTML into w, like the package level RenderTo does.
+func (r *Renderer) RenderTo(w io.Writer, doc *Document) error {
+	hw := &Writer{
+		Writer:   bufio.NewWriter(w),
+		renderer: r,
+	}
+	err := hw.render(doc)
+	if e := hw.Flush(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// A Writer is what nodes are rendered into.
+//
+// Write errors are remembered by the bufio.Writer and returned by RenderTo,
+// so they need not be checked while rendering.
+type Writer struct {
+	*bufio.Writer
+
+	renderer   *Renderer
+	extensions Extension // of the document being rendered
+
+	inFootnotes bool // rendering the footnote section
+}
+
+func (w *Writer) render(doc *Document) (err error) {
+	defer recoverError(&err)
+	w.extensions = doc.extensions
+	return w.Render(doc)
+}
+
+// WriteHTML writes raw HTML, with disallowed tags filtered out
+// if the document is parsed with ExtTagFilter.
+func (w *Writer) WriteHTML(s string) {
+	if w.extensions&ExtTagFilter != 0 {
+		s = filterTags(s)
+	}
+	w.WriteString(s)
+}
+
+// WriteAttributes writes attrs as HTML attributes, each with a leading space.
+//
+// Attributes with names that are not valid are left out, and so are those
+// named in written, which the element has been written with, as in "href",
+// and event handlers, as in "onclick", unless WithUnsafeAttributes is given.
+func (w *Writer) WriteAttributes(attrs Attributes, written ...string) {
+	for _, attr := range attrs {
+		if !isAttributeName(attr.Key) || w.isWrittenOrUnsafe(attr.Key, written) {
+			continue
 		}
-		s += ">"
-		for _, inline := range it.Inlines {
-			s += toInline(inline)
+		w.WriteString(" " + attr.Key + `="`)
+		w.WriteEscaped(attr.Value)
+		w.WriteString(`"`)
+	}
+}
+
+// isWrittenOrUnsafe tells whether the attribute key is one of written,
+// or is an event handler that is not to be written.
+func (w *Writer) isWrittenOrUnsafe(key string, written []string) bool {
+	key = strings.ToLower(key)
+	for _, k := range written {
+		if key == k {
+			return true
 		}
-		s += "</a>"
-	case *Image:
-		s += fmt.Sprintf(`<img src="%s"`, escapeText(urlEncode(it.Link)))
-		s += fmt.Sprintf(` alt="%s"`, escapeText(it.Alt))
-		if it.Title != "" {
-			s += fmt.Sprintf(` title="%s"`, escapeText(it.Title))
+	}
+	return strings.HasPrefix(key, "on") && !w.renderer.unsafeAttributes
+}
+
+// Render renders node and its children.
+func (w *Writer) Render(node Node) (err error) {
+	Walk(node, func(n Node, entering bool) WalkStatus {
+		fn, ok := w.renderer.funcs[n.Kind()]
+		if d, isDiv := n.(*FencedDiv); isDiv {
+			if dfn, found := w.renderer.divFuncs[d.Name]; found {
+				fn, ok = dfn, true
+			}
 		}
-		s += " />"
-	case *Emphasis:
-		switch it.Delimiter {
-		default:
-			panic("unknown delimiter")
-		case "*", "_":
-			s += "<em>"
-		case "**", "__":
-			s += "<strong>"
+		if !ok {
+			fn = renderDefault
 		}
-
-		for _, i := range it.Inlines {
-			s += toInline(i)
+		status, e := fn(w, n, entering)
+		if e != nil {
+			err = e
+			return WalkStop
 		}
+		return status
+	})
+	return err
+}
 
-		switch it.Delimiter {
-		default:
-			panic("unknown delimiter")
-		case "*", "_":
-			s += "</em>"
-		case "**", "__":
-			s += "</strong>"
-		}
-	case *HardLineBreak:
-		s += "<br />\n"
-	case *SoftLineBreak:
-		s += "\n"
-	case *CodeSpan:
-		s += "<code>" + escapeText(it.TextContent()) + "</code>"
-	case *HtmlTag:
-		s += it.Tag
-	}
-	return s
+// RenderDefault renders node as if no RenderFunc were registered for it.
+func (w *Writer) RenderDefault(node Node, entering bool) (WalkStatus, error) {
+	return renderDefault(w, node, entering)
 }
 
-func toHTML(block Blocker) string {
-	s := ""
-	switch typed := block.(type) {
+var htmlEscaper = strings.NewReplacer(
+	`&`, "&amp;",
+	`"`, "&quot;",
+	`<`, "&lt;",
+	`>`, "&gt;",
+)
+
+// WriteEscaped writes s with HTML special characters escaped.
+func (w *Writer) WriteEscaped(s string) {
+	htmlEscaper.WriteString(w, s)
+}
+
+// WriteURL writes s percent-encoded and escaped, for use as an attribute value.
+func (w *Writer) WriteURL(s string) {
+	w.WriteEscaped(urlEncode(s))
+}
+
+func renderDefault(w *Writer, node Node, entering bool) (WalkStatus, error) {
+	switch typed := node.(type) {
 	default:
-		panic("unhandled block: " + reflect.TypeOf(typed).String())
+		return WalkStop, internalErrorf("unhandled node: %s", node.Kind())
+	case *Document:
+		if !entering {
+			if err := renderFootnotes(w, typed); err != nil {
+				return WalkStop, err
+			}
+		}
+	case *BlankLine, *LinkReferenceDefinition:
+		break
 	case *Paragraph:
 		// HACK: contents are parsed as link reference definitions.
-		if len(typed.Inlines) == 0 {
+		if len(typed.Inlines) == 0 || typed.Tight {
 			break
 		}
-		if !typed.Tight {
-			s += "<p>"
-		}
-		for _, inline := range typed.Inlines {
-			s += toInline(inline)
-		}
-		if !typed.Tight {
-			s += "</p>\n"
+		if entering {
+			w.WriteString("<p")
+			w.WriteAttributes(typed.Attributes)
+			w.WriteString(">")
+		} else {
+			w.WriteString("</p>\n")
 		}
-	case *BlankLine:
-		break
 	case *HorizontalRule:
-		_ = typed
-		s += "<hr />\n"
+		if entering {
+			w.WriteString("<hr")
+			w.WriteAttributes(typed.Attributes)
+			w.WriteString(" />\n")
+		}
 	case *Heading:
-		s += fmt.Sprintf("<h%d>", typed.Level)
-		for _, inline := range typed.Inlines {
-			s += toInline(inline)
+		if entering {
+			w.WriteString("<h" + strconv.Itoa(typed.Level))
+			if typed.ID != "" {
+				w.WriteString(` id="`)
+				w.WriteEscaped(typed.ID)
+				w.WriteString(`"`)
+			}
+			w.WriteAttributes(typed.Attributes, "id")
+			w.WriteString(">")
+		} else {
+			w.WriteString("</h" + strconv.Itoa(typed.Level) + ">\n")
 		}
-		s += fmt.Sprintf("</h%d>\n", typed.Level)
 	case *CodeBlock:
+		if !entering {
+			break
+		}
+		w.WriteString("<pre")
+		w.WriteAttributes(typed.Attributes)
+		w.WriteString(">")
 		if typed.Lang == "" {
-			s += fmt.Sprintf("<pre><code>%s</code></pre>\n", escapeText(typed.String()))
+			w.WriteString("<code>")
 		} else {
 			lang := typed.Lang
 			if p := strings.IndexAny(lang, " \t"); p != -1 {
 				lang = lang[:p]
 			}
-			s += fmt.Sprintf(
-				"<pre><code class=\"language-%s\">%s</code></pre>\n",
-				lang,
-				escapeText(typed.String()),
-			)
+			w.WriteString(`<code class="language-`)
+			w.WriteEscaped(lang)
+			w.WriteString(`">`)
 		}
+		w.WriteEscaped(typed.String())
+		w.WriteString("</code></pre>\n")
 	case *BlockQuote:
-		s += "<blockquote>\n"
-		for _, b := range typed.blocks {
-			s += toHTML(b)
+		if entering {
+			w.WriteString("<blockquote")
+			w.WriteAttributes(typed.Attributes)
+			w.WriteString(">\n")
+		} else {
+			w.WriteString("</blockquote>\n")
+		}
+	case *FencedDiv:
+		if !entering {
+			w.WriteString("</div>\n")
+			break
+		}
+		var attrs Attributes
+		if typed.Name != "" {
+			attrs = Attributes{{Key: "class", Value: typed.Name}}
+		}
+		attrs.merge(typed.Attributes)
+		w.WriteString("<div")
+		w.WriteAttributes(attrs)
+		w.WriteString(">\n")
+	case *Alert:
+		if !entering {
+			w.WriteString("</div>\n")
+			break
 		}
-		s += "</blockquote>\n"
+		attrs := Attributes{{Key: "class", Value: "markdown-alert markdown-alert-" + typed.Type}}
+		attrs.merge(typed.Attributes)
+		w.WriteString("<div")
+		w.WriteAttributes(attrs)
+		w.WriteString(">\n")
+		w.WriteString(`<p class="markdown-alert-title">`)
+		w.WriteEscaped(typed.Title)
+		w.WriteString("</p>\n")
 	case *List:
-		typed.deduceIsTight()
-		if typed.Ordered {
+		switch {
+		case typed.Ordered && entering:
 			if typed.Start == 1 {
-				s += "<ol>\n"
+				w.WriteString("<ol")
 			} else {
-				s += fmt.Sprintf("<ol start=\"%d\">\n", typed.Start)
+				fmt.Fprintf(w, "<ol start=\"%d\"", typed.Start)
 			}
+			w.WriteAttributes(typed.Attributes, "start")
+			w.WriteString(">\n")
+		case typed.Ordered:
+			w.WriteString("</ol>\n")
+		case entering:
+			w.WriteString("<ul")
+			w.WriteAttributes(typed.Attributes)
+			w.WriteString(">\n")
+		default:
+			w.WriteString("</ul>\n")
+		}
+	case *ListItem:
+		if !entering {
+			w.WriteString("</li>\n")
+			break
+		}
+		return renderListItem(w, typed)
+	case *DefinitionList:
+		if entering {
+			w.WriteString("<dl")
+			w.WriteAttributes(typed.Attributes)
+			w.WriteString(">\n")
 		} else {
-			s += "<ul>\n"
+			w.WriteString("</dl>\n")
 		}
-
-		for _, item := range typed.Items {
-			if _, ok := item.(*BlankLine); ok {
-				continue
+	case *DefinitionTerm:
+		if entering {
+			w.WriteString("<dt>")
+		} else {
+			w.WriteString("</dt>\n")
+		}
+	case *DefinitionDescription:
+		if !entering {
+			w.WriteString("</dd>\n")
+			break
+		}
+		return renderDefinitionDescription(w, typed)
+	case *TableOfContents:
+		if entering {
+			renderTOC(w, typed.Entries)
+		}
+	case *Table:
+		if !entering {
+			w.WriteString("</table>\n")
+			break
+		}
+		return renderTable(w, typed)
+	case *TableRow:
+		if entering {
+			w.WriteString("<tr>\n")
+		} else {
+			w.WriteString("</tr>\n")
+		}
+	case *TableCell:
+		tag := "td"
+		if typed.Header {
+			tag = "th"
+		}
+		if !entering {
+			w.WriteString("</" + tag + ">\n")
+			break
+		}
+		w.WriteString("<" + tag)
+		switch typed.Align {
+		case AlignLeft:
+			w.WriteString(` align="left"`)
+		case AlignCenter:
+			w.WriteString(` align="center"`)
+		case AlignRight:
+			w.WriteString(` align="right"`)
+		}
+		w.WriteString(">")
+	case *HtmlBlock:
+		if entering {
+			for _, line := range typed.Lines {
+				w.WriteHTML(string(line))
 			}
+		}
+	case *Text:
+		if entering {
+			w.WriteEscaped(typed.Text)
+		}
+	case *Link:
+		if !entering {
+			w.WriteString("</a>")
+			break
+		}
+		w.WriteString(`<a href="`)
+		w.WriteURL(typed.Link)
+		w.WriteString(`"`)
+		if typed.Title != "" {
+			w.WriteString(` title="`)
+			w.WriteEscaped(typed.Title)
+			w.WriteString(`"`)
+		}
+		w.WriteAttributes(typed.Attributes, "href", "title")
+		w.WriteString(">")
+	case *Image:
+		if !entering {
+			break
+		}
+		w.WriteString(`<img src="`)
+		w.WriteURL(typed.Link)
+		w.WriteString(`" alt="`)
+		w.WriteEscaped(typed.Alt)
+		w.WriteString(`"`)
+		if typed.Title != "" {
+			w.WriteString(` title="`)
+			w.WriteEscaped(typed.Title)
+			w.WriteString(`"`)
+		}
+		w.WriteAttributes(typed.Attributes, "src", "alt", "title")
+		w.WriteString(" />")
+		// The description has been rendered as Alt.
+		return WalkSkipChildren, nil
+	case *Emphasis:
+		var tag string
+		switch typed.Delimiter {
+		default:
+			return WalkStop, internalErrorf("unknown delimiter: %q", typed.Delimiter)
+		case "*", "_":
+			tag = "em"
+		case "**", "__":
+			tag = "strong"
+		}
+		if entering {
+			w.WriteString("<" + tag + ">")
+		} else {
+			w.WriteString("</" + tag + ">")
+		}
+	case *Strikethrough:
+		if entering {
+			w.WriteString("<del>")
+		} else {
+			w.WriteString("</del>")
+		}
+	case *FootnoteDefinition:
+		// Footnotes are rendered at the end of the document, not where they are defined.
+		if !w.inFootnotes {
+			return WalkSkipChildren, nil
+		}
+		if !entering {
+			w.WriteString("</li>\n")
+			break
+		}
+		return renderFootnoteDefinition(w, typed)
+	case *FootnoteReference:
+		if entering {
+			renderFootnoteReference(w, typed)
+		}
+	case *HardLineBreak:
+		if entering {
+			w.WriteString("<br />\n")
+		}
+	case *SoftLineBreak:
+		if entering {
+			w.WriteString("\n")
+		}
+	case *MathInline:
+		if !entering {
+			break
+		}
+		if typed.Display {
+			w.WriteString(`<span class="math display">\[`)
+			w.WriteEscaped(typed.Literal)
+			w.WriteString(`\]</span>`)
+		} else {
+			w.WriteString(`<span class="math inline">\(`)
+			w.WriteEscaped(typed.Literal)
+			w.WriteString(`\)</span>`)
+		}
+	case *MathBlock:
+		if !entering {
+			break
+		}
+		attrs := Attributes{{Key: "class", Value: "math display"}}
+		attrs.merge(typed.Attributes)
+		w.WriteString("<div")
+		w.WriteAttributes(attrs)
+		w.WriteString(">\\[\n")
+		w.WriteEscaped(typed.String())
+		w.WriteString("\\]</div>\n")
+	case *Emoji:
+		if entering {
+			renderEmoji(w, typed)
+		}
+	case *CodeSpan:
+		if entering {
+			w.WriteString("<code>")
+			w.WriteEscaped(typed.TextContent())
+			w.WriteString("</code>")
+		}
+	case *HtmlTag:
+		if entering {
+			w.WriteHTML(typed.Tag)
+		}
+	}
+	return WalkContinue, nil
+}
 
-			itemBlocks := item.(*ListItem).blocks
+// renderListItem renders the opening tag and the blocks of li.
+//
+// Paragraphs in a tight list are not wrapped in <p> tags, so
+// line endings are written around them instead.
+func renderListItem(w *Writer, li *ListItem) (WalkStatus, error) {
+	addNewLine := true
+	if len(li.blocks) > 0 {
+		if p, ok := li.blocks[0].(*Paragraph); ok && p.Tight {
+			addNewLine = false
+		}
+	} else {
+		addNewLine = false
+	}
+	w.WriteString("<li>")
+	switch li.Task {
+	case TaskUnchecked:
+		w.WriteString(`<input type="checkbox" disabled="" /> `)
+	case TaskChecked:
+		w.WriteString(`<input type="checkbox" checked="" disabled="" /> `)
+	}
+	if addNewLine {
+		w.WriteString("\n")
+	}
+	return renderBlocks(w, li.blocks)
+}
 
-			addNewLine := true
-			if len(itemBlocks) > 0 {
-				if _, ok := itemBlocks[0].(*Paragraph); ok {
-					if typed.Tight {
-						addNewLine = false
-					}
-				}
-			} else {
-				addNewLine = false
-			}
-			s += "<li>"
-			if addNewLine {
-				s += "\n"
+// renderBlocks renders the blocks of a list item or a description,
+// with a line ending after each tight paragraph that is followed by a block.
+func renderBlocks(w *Writer, blocks []Blocker) (WalkStatus, error) {
+	var lastParagraph *Paragraph
+	for _, block := range blocks {
+		if _, ok := block.(*BlankLine); ok {
+			continue
+		}
+		if lastParagraph != nil && lastParagraph.Tight {
+			w.WriteString("\n")
+		}
+		lastParagraph, _ = block.(*Paragraph)
+		if err := w.Render(block); err != nil {
+			return WalkStop, err
+		}
+	}
+	return WalkSkipChildren, nil
+}
+
+// renderDefinitionDescription renders the opening tag and the blocks of dd.
+func renderDefinitionDescription(w *Writer, dd *DefinitionDescription) (WalkStatus, error) {
+	w.WriteString("<dd>")
+	for _, block := range dd.blocks {
+		if _, ok := block.(*BlankLine); ok {
+			continue
+		}
+		if p, ok := block.(*Paragraph); !ok || !p.Tight {
+			w.WriteString("\n")
+		}
+		break
+	}
+	return renderBlocks(w, dd.blocks)
+}
+
+// renderTable renders the opening tag and the rows of t,
+// with the header row in <thead> and the data rows, if any, in <tbody>.
+func renderTable(w *Writer, t *Table) (WalkStatus, error) {
+	w.WriteString("<table")
+	w.WriteAttributes(t.Attributes)
+	w.WriteString(">\n<thead>\n")
+	if err := w.Render(t.Header); err != nil {
+		return WalkStop, err
+	}
+	w.WriteString("</thead>\n")
+	if len(t.Rows) > 0 {
+		w.WriteString("<tbody>\n")
+		for _, row := range t.Rows {
+			if err := w.Render(row); err != nil {
+				return WalkStop, err
 			}
+		}
+		w.WriteString("</tbody>\n")
+	}
+	return WalkSkipChildren, nil
+}
 
-			var lastParagraph *Paragraph
-			for _, block := range itemBlocks {
-				if _, ok := block.(*BlankLine); ok {
-					continue
-				}
-				if lastParagraph != nil && lastParagraph.Tight {
-					s += "\n"
-				}
-				if p, ok := block.(*Paragraph); ok {
-					p.Tight = typed.Tight
-					lastParagraph = p
-				} else {
-					lastParagraph = nil
-				}
-				s += toHTML(block)
+// footnoteID makes the id of the footnote labeled label, to which
+// "fn-" and "fnref-" are prefixed for the footnote and its references.
+func footnoteID(label string, ref int) string {
+	id := urlEncode(strings.ToLower(label))
+	if ref > 1 {
+		id += "-" + strconv.Itoa(ref)
+	}
+	return id
+}
+
+// renderFootnoteReference renders fr as a superscript link to its footnote.
+func renderFootnoteReference(w *Writer, fr *FootnoteReference) {
+	w.WriteString(`<sup class="footnote-ref"><a href="#fn-`)
+	w.WriteEscaped(footnoteID(fr.Label, 1))
+	w.WriteString(`" id="fnref-`)
+	w.WriteEscaped(footnoteID(fr.Label, fr.Ref))
+	w.WriteString(`" data-footnote-ref>`)
+	w.WriteString(strconv.Itoa(fr.Index))
+	w.WriteString(`</a></sup>`)
+}
+
+// renderFootnotes renders the referenced footnotes of doc
+// in a section at the end of the document.
+func renderFootnotes(w *Writer, doc *Document) error {
+	if len(doc.footnoteList) == 0 {
+		return nil
+	}
+	w.WriteString("<section class=\"footnotes\" data-footnotes>\n<ol>\n")
+	w.inFootnotes = true
+	defer func() { w.inFootnotes = false }()
+	for _, fd := range doc.footnoteList {
+		if err := w.Render(fd); err != nil {
+			return err
+		}
+	}
+	w.WriteString("</ol>\n</section>\n")
+	return nil
+}
+
+// renderFootnoteDefinition renders the opening tag and the blocks of fd,
+// with links back to the references at the end of its last paragraph,
+// or in a paragraph of their own if the last block is not a paragraph.
+func renderFootnoteDefinition(w *Writer, fd *FootnoteDefinition) (WalkStatus, error) {
+	w.WriteString(`<li id="fn-`)
+	w.WriteEscaped(footnoteID(fd.Label, 1))
+	w.WriteString("\">\n")
+
+	var blocks []Blocker
+	for _, block := range fd.blocks {
+		if _, ok := block.(*BlankLine); !ok {
+			blocks = append(blocks, block)
+		}
+	}
+
+	var last *Paragraph
+	if n := len(blocks); n > 0 {
+		if pp, ok := blocks[n-1].(*Paragraph); ok && len(pp.Inlines) > 0 {
+			last = pp
+			blocks = blocks[:n-1]
+		}
+	}
+	for _, block := range blocks {
+		if err := w.Render(block); err != nil {
+			return WalkStop, err
+		}
+	}
+
+	w.WriteString("<p>")
+	if last != nil {
+		for _, inline := range last.Inlines {
+			if err := w.Render(inline); err != nil {
+				return WalkStop, err
 			}
-			s += "</li>\n"
 		}
+		w.WriteString(" ")
+	}
+	for ref := 1; ref <= fd.RefCount; ref++ {
+		if ref > 1 {
+			w.WriteString(" ")
+		}
+		idx := strconv.Itoa(fd.Index)
+		if ref > 1 {
+			idx += "-" + strconv.Itoa(ref)
+		}
+		w.WriteString(`<a href="#fnref-`)
+		w.WriteEscaped(footnoteID(fd.Label, ref))
+		w.WriteString(`" class="footnote-backref" data-footnote-backref data-footnote-backref-idx="`)
+		w.WriteString(idx)
+		w.WriteString(`" aria-label="Back to reference `)
+		w.WriteString(idx)
+		w.WriteString(`">↩`)
+		if ref > 1 {
+			w.WriteString(`<sup class="footnote-ref">` + strconv.Itoa(ref) + `</sup>`)
+		}
+		w.WriteString(`</a>`)
+	}
+	w.WriteString("</p>\n")
+	return WalkSkipChildren, nil
+}
 
-		if typed.Ordered {
-			s += "</ol>\n"
+// renderTOC renders entries as a list, in which each entry is a link
+// to its heading, followed by the list of its children.
+// A heading without an id is not linked.
+func renderTOC(w *Writer, entries []*TOCEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	w.WriteString("<ul>\n")
+	for _, entry := range entries {
+		w.WriteString("<li>")
+		if entry.ID != "" {
+			w.WriteString(`<a href="#`)
+			w.WriteURL(entry.ID)
+			w.WriteString(`">`)
+			w.WriteEscaped(entry.Text)
+			w.WriteString("</a>")
 		} else {
-			s += "</ul>\n"
+			w.WriteEscaped(entry.Text)
 		}
-	case *HtmlBlock:
-		for _, line := range typed.Lines {
-			s += string(line)
+		if len(entry.Children) > 0 {
+			w.WriteString("\n")
+			renderTOC(w, entry.Children)
 		}
+		w.WriteString("</li>\n")
+	}
+	w.WriteString("</ul>\n")
+}
+
+// renderEmoji renders an emoji as its Unicode sequence,
+// or as an image if the renderer is given WithEmojiImages.
+func renderEmoji(w *Writer, e *Emoji) {
+	if w.renderer.emojiURL == "" {
+		w.WriteEscaped(e.Emoji)
+		return
 	}
-	return s
+	src := strings.NewReplacer(
+		"{shortcode}", e.Shortcode,
+		"{codepoints}", e.Codepoints(),
+	).Replace(w.renderer.emojiURL)
+	w.WriteString(`<img class="emoji" src="`)
+	w.WriteURL(src)
+	w.WriteString(`" alt="`)
+	w.WriteEscaped(e.Emoji)
+	w.WriteString(`" title=":`)
+	w.WriteEscaped(e.Shortcode)
+	w.WriteString(`:" />`)
 }
@@ -0,0 +1,90 @@
+package taomd
+
+import (
+	"errors"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func BenchmarkRender(b *testing.B) {
+	corpus, size := loadCorpus(b)
+	docs := make([]*Document, len(corpus))
+	for i, md := range corpus {
+		docs[i] = Parse(strings.NewReader(md))
+	}
+	b.ReportAllocs()
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, doc := range docs {
+			RenderTo(ioutil.Discard, doc)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	doc := Parse(strings.NewReader("# foo\n\n*bar*\n"))
+
+	// Headings one level down, with their inlines rendered by default.
+	heading := func(w *Writer, node Node, entering bool) (WalkStatus, error) {
+		h := node.(*Heading)
+		if entering {
+			w.WriteString("<h" + strconv.Itoa(h.Level+1) + ">")
+		} else {
+			w.WriteString("</h" + strconv.Itoa(h.Level+1) + ">\n")
+		}
+		return WalkContinue, nil
+	}
+	// Emphases by RenderDefault, but their texts left out.
+	emphasis := func(w *Writer, node Node, entering bool) (WalkStatus, error) {
+		if _, err := w.RenderDefault(node, entering); err != nil {
+			return WalkStop, err
+		}
+		return WalkSkipChildren, nil
+	}
+
+	r := NewRenderer(WithRenderFunc(KindHeading, heading))
+	r.Register(KindEmphasis, emphasis)
+	if html, want := r.Render(doc), "<h2>foo</h2>\n<p><em></em></p>\n"; html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+
+	// A nil RenderFunc restores the default.
+	r.Register(KindHeading, nil)
+	r.Register(KindEmphasis, nil)
+	if html, want := r.Render(doc), Render(doc); html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+}
+
+func TestRegisterError(t *testing.T) {
+	failed := errors.New("failed")
+	r := NewRenderer(WithRenderFunc(KindEmphasis, func(w *Writer, node Node, entering bool) (WalkStatus, error) {
+		return WalkStop, failed
+	}))
+	var sb strings.Builder
+	err := r.RenderTo(&sb, Parse(strings.NewReader("# foo\n\n*bar*\n")))
+	if err != failed {
+		t.Fatalf("error: %v", err)
+	}
+	if html, want := sb.String(), "<h1>foo</h1>\n<p>"; html != want {
+		t.Errorf("want what has been rendered:\n%s\ngiven:\n%s", want, html)
+	}
+}
+
+func TestRenderCodeBlockLang(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "```\"onmouseover=alert(1)\nx\n```\n",
+			HTML:     "<pre><code class=\"language-&quot;onmouseover=alert(1)\">x\n</code></pre>\n",
+			Section:  "Fenced code blocks",
+		},
+		{
+			Markdown: "``` a&b<c>\nx\n```\n",
+			HTML:     "<pre><code class=\"language-a&amp;b&lt;c&gt;\">x\n</code></pre>\n",
+			Section:  "Fenced code blocks",
+		},
+	})
+}
@@ -0,0 +1,117 @@
+package taomd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// An Example from spec.json, or one written the same way.
+type Example struct {
+	Markdown string `json:"markdown"`
+	HTML     string `json:"html"`
+	Example  int    `json:"example"`
+	Section  string `json:"section"`
+}
+
+// loadExamples loads all examples from a spec.json.
+func loadExamples(t testing.TB, path string) []*Example {
+	fp, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+
+	var examples []*Example
+	if err := json.NewDecoder(fp).Decode(&examples); err != nil {
+		t.Fatal(err)
+	}
+	return examples
+}
+
+// loadPassed loads the numbers of the examples known to pass.
+func loadPassed(t *testing.T, path string) map[int]bool {
+	fp, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+
+	passed := make(map[int]bool)
+	scn := bufio.NewScanner(fp)
+	for scn.Scan() {
+		n, err := strconv.Atoi(scn.Text())
+		if err != nil {
+			t.Fatal(err)
+		}
+		passed[n] = true
+	}
+	return passed
+}
+
+// testExamples renders each example parsed with opts, and compares it with the HTML.
+func testExamples(t *testing.T, examples []*Example, opts ...Option) {
+	t.Helper()
+	for _, example := range examples {
+		doc, err := ParseReader(strings.NewReader(example.Markdown), opts...)
+		if err != nil {
+			t.Errorf("example %d (%s): %v", example.Example, example.Section, err)
+			continue
+		}
+		if html := Render(doc); html != example.HTML {
+			t.Errorf("example %d (%s):\nmarkdown:\n%s\nwant:\n%s\ngot:\n%s",
+				example.Example, example.Section, example.Markdown, example.HTML, html)
+		}
+	}
+}
+
+// TestSpec tests the examples of the CommonMark spec known to pass,
+// as listed in cmd/taomd/pass.txt.
+func TestSpec(t *testing.T) {
+	passed := loadPassed(t, "cmd/taomd/pass.txt")
+	var examples []*Example
+	for _, example := range loadExamples(t, "testdata/spec.json") {
+		if passed[example.Example] {
+			examples = append(examples, example)
+		}
+	}
+	testExamples(t, examples, WithExtensions(CommonMark))
+}
+
+func TestSpecRegressions(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "> foo\n    - bar\n",
+			HTML:     "<blockquote>\n<p>foo\n- bar</p>\n</blockquote>\n",
+			Section:  "Block quotes",
+		},
+		{
+			Markdown: "- foo\n  bar\n      - baz\n",
+			HTML:     "<ul>\n<li>foo\nbar\n- baz</li>\n</ul>\n",
+			Section:  "List items",
+		},
+		{
+			Markdown: "> foo\n>     code\n",
+			HTML:     "<blockquote>\n<p>foo\ncode</p>\n</blockquote>\n",
+			Section:  "Block quotes",
+		},
+		{
+			Markdown: ">     code\n",
+			HTML:     "<blockquote>\n<pre><code>code\n</code></pre>\n</blockquote>\n",
+			Section:  "Block quotes",
+		},
+		{
+			Markdown: "-  ```\n   aaa\n    bbb\n   ```\n",
+			HTML:     "<ul>\n<li>\n<pre><code>aaa\n bbb\n</code></pre>\n</li>\n</ul>\n",
+			Section:  "Fenced code blocks",
+		},
+		{
+			Markdown: "> ```\n>  aaa\n> ```\n",
+			HTML:     "<blockquote>\n<pre><code> aaa\n</code></pre>\n</blockquote>\n",
+			Section:  "Fenced code blocks",
+		},
+	}, WithExtensions(CommonMark))
+}
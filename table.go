@@ -0,0 +1,308 @@
+package taomd
+
+import (
+	"strings"
+)
+
+// A Table is a GFM table.
+// https://github.github.com/gfm/#tables-extension-
+//
+// A table is an arrangement of data with rows and columns, consisting of
+// a single header row, a delimiter row separating the header from the data,
+// and zero or more data rows.
+type Table struct {
+	Span
+	Attributes
+
+	// The alignment of each column, from the delimiter row.
+	Aligns []Alignment
+
+	Header *TableRow
+	Rows   []*TableRow
+
+	// The paragraph whose last line is the header row,
+	// until the line is taken from it.
+	paragraph *Paragraph
+	line      []rune // the delimiter row
+
+	closed bool
+}
+
+// Alignment tells how the content of a table column is aligned.
+type Alignment int
+
+const (
+	// AlignNone is for a column with no colons in the delimiter row.
+	AlignNone Alignment = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// A TableRow is the header row or a data row of a table.
+type TableRow struct {
+	Span
+
+	// There are always as many cells as the table has columns.
+	Cells []*TableCell
+}
+
+// A TableCell is a cell of a table row.
+type TableCell struct {
+	Span
+
+	Align Alignment
+
+	// Is the cell in the header row?
+	Header bool
+
+	Inlines []Inline
+
+	text  string
+	start Position
+}
+
+// Children returns the header row and the data rows.
+func (t *Table) Children() []Node {
+	nodes := []Node{t.Header}
+	for _, row := range t.Rows {
+		nodes = append(nodes, row)
+	}
+	return nodes
+}
+
+// Children returns the cells of the row.
+func (r *TableRow) Children() []Node {
+	nodes := make([]Node, len(r.Cells))
+	for i, cell := range r.Cells {
+		nodes[i] = cell
+	}
+	return nodes
+}
+
+// Children returns the inlines of the cell.
+func (c *TableCell) Children() []Node {
+	return inlinesToNodes(c.Inlines)
+}
+
+func (c *TableCell) parseInlines(p *Parser) {
+	c.Inlines = p.parseInlines(c.text, []Position{c.start})
+}
+
+func (t *Table) AddLine(p *Parser, s []rune) bool {
+	if t.closed {
+		return false
+	}
+
+	// The table is broken at the first empty line,
+	// or beginning of another block-level structure.
+	var blocks []Blocker
+	p.tip = t
+	if !p.addLine(&blocks, s) {
+		p.fail(internalErrorf("table row not added"))
+		return false
+	}
+	if len(blocks) == 1 {
+		if _, ok := blocks[0].(*Paragraph); ok {
+			_, s = skipPrefixSpaces(s, -1)
+			t.Rows = append(t.Rows, t.newRow(s, p.posOf(s), p.lineEnd(), false))
+			return true
+		}
+	}
+
+	t.closed = true
+	return false
+}
+
+// tryParseTableStart parses s as the delimiter row of a table
+// whose header row is the last line of pp.
+//
+// The header row must match the delimiter row in the number of cells.
+// If not, a table will not be recognized.
+func tryParseTableStart(pp *Paragraph, s []rune) *Table {
+	if len(pp.texts) == 0 {
+		return nil
+	}
+
+	aligns, ok := parseTableDelimiterRow(s)
+	if !ok {
+		return nil
+	}
+
+	header, _ := splitTableRow([]rune(pp.texts[len(pp.texts)-1]))
+	if len(header) != len(aligns) {
+		return nil
+	}
+
+	return &Table{
+		Aligns:    aligns,
+		paragraph: pp,
+		line:      s,
+	}
+}
+
+// parseTableDelimiterRow parses s as a delimiter row,
+// and returns the alignments of its cells.
+func parseTableDelimiterRow(s []rune) ([]Alignment, bool) {
+	cells, _ := splitTableRow(s)
+	aligns := make([]Alignment, 0, len(cells))
+	for _, cell := range cells {
+		align, ok := parseTableDelimiter(cell)
+		if !ok {
+			return nil, false
+		}
+		aligns = append(aligns, align)
+	}
+	return aligns, true
+}
+
+// parseTableDelimiter parses a cell of the delimiter row, which
+// consists of one or more hyphens (-), and optionally, a leading
+// or trailing colon (:), or both, to indicate left, right, or center alignment.
+func parseTableDelimiter(cell []rune) (Alignment, bool) {
+	left := len(cell) > 0 && cell[0] == ':'
+	if left {
+		cell = cell[1:]
+	}
+	right := len(cell) > 0 && cell[len(cell)-1] == ':'
+	if right {
+		cell = cell[:len(cell)-1]
+	}
+	if len(cell) == 0 || strings.Trim(string(cell), "-") != "" {
+		return AlignNone, false
+	}
+	switch {
+	case left && right:
+		return AlignCenter, true
+	case left:
+		return AlignLeft, true
+	case right:
+		return AlignRight, true
+	}
+	return AlignNone, true
+}
+
+// splitTableRow splits s into cells, trimmed of white spaces, and
+// returns them with their offsets in s.
+//
+// Cells are separated by pipes (|). A leading and trailing pipe is
+// recommended for clarity of reading, but not required.
+// A pipe escaped by a backslash does not separate cells.
+func splitTableRow(s []rune) ([][]rune, []int) {
+	isSpace := func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n'
+	}
+	escaped := func(i int) bool {
+		return i > 0 && s[i-1] == '\\'
+	}
+
+	start, end := 0, len(s)
+	for start < end && isSpace(s[start]) {
+		start++
+	}
+	for end > start && isSpace(s[end-1]) {
+		end--
+	}
+	if start < end && s[start] == '|' {
+		start++
+	}
+	if end > start && s[end-1] == '|' && !escaped(end-1) {
+		end--
+	}
+
+	var cells [][]rune
+	var offsets []int
+	for i := start; ; i++ {
+		if i < end && (s[i] != '|' || escaped(i)) {
+			continue
+		}
+		j, k := start, i
+		for j < k && isSpace(s[j]) {
+			j++
+		}
+		for k > j && isSpace(s[k-1]) {
+			k--
+		}
+		cells = append(cells, s[j:k])
+		offsets = append(offsets, j)
+		if i >= end {
+			break
+		}
+		start = i + 1
+	}
+
+	return cells, offsets
+}
+
+// newRow makes a row of s, which starts at start and ends at end.
+//
+// The remainder of the row's cells are empty if there are fewer cells
+// than the number of columns, and excess cells are ignored.
+func (t *Table) newRow(s []rune, start Position, end Position, header bool) *TableRow {
+	row := &TableRow{
+		Span: Span{Start: start, End: end},
+	}
+	cells, offsets := splitTableRow(s)
+	for i, align := range t.Aligns {
+		cell := &TableCell{
+			Span:   Span{Start: end, End: end},
+			Align:  align,
+			Header: header,
+			start:  end,
+		}
+		if i < len(cells) {
+			cell.start = start.advance(s[:offsets[i]])
+			cell.Span = Span{Start: cell.start, End: cell.start.advance(cells[i])}
+			// The pipes inside other inline spans can be included
+			// in a table cell by escaping them.
+			cell.text = strings.Replace(string(cells[i]), `\|`, `|`, -1)
+		}
+		row.Cells = append(row.Cells, cell)
+	}
+	return row
+}
+
+// tryMergeTableHeader takes the header row of a newly started table
+// from the paragraph before it. A table that has no paragraph before it,
+// which happens if the paragraph belongs to another container,
+// is not a table but a paragraph.
+func (p *Parser) tryMergeTableHeader(pbs *[]Blocker) {
+	blocks := *pbs
+	n := len(blocks)
+	if n < 1 {
+		return
+	}
+
+	t, ok := blocks[n-1].(*Table)
+	if !ok || t.paragraph == nil {
+		return
+	}
+	pp := t.paragraph
+	t.paragraph = nil
+
+	if n < 2 || blocks[n-2] != pp {
+		blocks[n-1] = &Paragraph{
+			Span:   t.Span,
+			texts:  []string{string(t.line)},
+			starts: []Position{t.Start},
+		}
+		return
+	}
+
+	last := len(pp.texts) - 1
+	line := []rune(pp.texts[last])
+	start := pp.starts[last]
+	end := start.advance([]rune(strings.TrimSuffix(string(line), "\n")))
+	t.Header = t.newRow(line, start, end, true)
+	t.Start = start
+
+	pp.texts = pp.texts[:last]
+	pp.starts = pp.starts[:last]
+	if last == 0 {
+		*pbs = blocks[:n-2]
+		*pbs = append(*pbs, t)
+		return
+	}
+	prev := pp.starts[last-1]
+	pp.End = prev.advance([]rune(strings.TrimSuffix(pp.texts[last-1], "\n")))
+}
@@ -0,0 +1,62 @@
+package taomd
+
+import (
+	"strings"
+)
+
+// Some HTML tags are particularly troublesome to how HTML is interpreted
+// when rendered, and are filtered out by GFM.
+// https://github.github.com/gfm/#disallowed-raw-html-extension-
+var disallowedTags = []string{
+	"title",
+	"textarea",
+	"style",
+	"xmp",
+	"iframe",
+	"noembed",
+	"noframes",
+	"script",
+	"plaintext",
+}
+
+// filterTags replaces the leading < of disallowed tags in html with &lt;.
+// Other HTML is left intact.
+func filterTags(html string) string {
+	var sb strings.Builder
+	last := 0
+	for i := 0; i < len(html); i++ {
+		if html[i] == '<' && isDisallowedTag(html[i+1:]) {
+			sb.WriteString(html[last:i])
+			sb.WriteString("&lt;")
+			last = i + 1
+		}
+	}
+	if last == 0 {
+		return html
+	}
+	sb.WriteString(html[last:])
+	return sb.String()
+}
+
+// isDisallowedTag tells whether s, what follows a <, is an opening or closing
+// disallowed tag, whose name is followed by whitespace, > or />,
+// or by the end of the input.
+func isDisallowedTag(s string) bool {
+	s = strings.TrimPrefix(s, "/")
+	for _, tag := range disallowedTags {
+		if len(s) < len(tag) || !strings.EqualFold(s[:len(tag)], tag) {
+			continue
+		}
+		rest := s[len(tag):]
+		if rest == "" {
+			return true
+		}
+		switch rest[0] {
+		case ' ', '\t', '\n', '\v', '\f', '\r', '>':
+			return true
+		case '/':
+			return strings.HasPrefix(rest, "/>")
+		}
+	}
+	return false
+}
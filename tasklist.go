@@ -0,0 +1,69 @@
+package taomd
+
+// A Task tells whether a list item is a task list item, and whether it is checked.
+// https://github.github.com/gfm/#task-list-items-extension-
+type Task int
+
+const (
+	// TaskNone is for a list item that is not a task.
+	TaskNone Task = iota
+
+	// TaskUnchecked is for a list item that begins with [ ].
+	TaskUnchecked
+
+	// TaskChecked is for a list item that begins with [x] or [X].
+	TaskChecked
+)
+
+// parseTasks finds task list item markers, and takes them from the paragraphs.
+// It must be done before inlines are parsed, for a marker could be a link.
+func (doc *Document) parseTasks() {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		if li, ok := n.(*ListItem); ok && entering {
+			li.parseTask()
+		}
+		return WalkContinue
+	})
+}
+
+// parseTask parses the task list item marker of li.
+//
+// A task list item marker consists of optional spaces, a left bracket ([),
+// either a whitespace character or the letter x in either lowercase or uppercase,
+// and then a right bracket (]). It must be at the beginning of the first paragraph
+// of a list item, where the paragraph is the first block of the list item,
+// and be followed by whitespace.
+func (li *ListItem) parseTask() {
+	if len(li.blocks) == 0 {
+		return
+	}
+	pp, ok := li.blocks[0].(*Paragraph)
+	if !ok || len(pp.texts) == 0 {
+		return
+	}
+
+	line := []rune(pp.texts[0])
+	_, s := skipPrefixSpaces(line, -1)
+	if len(s) < 3 || s[0] != '[' || s[2] != ']' {
+		return
+	}
+	if len(s) > 3 && !any(s[3], ' ', '\t', '\n') {
+		return
+	}
+
+	var task Task
+	switch s[1] {
+	default:
+		return
+	case ' ', '\t':
+		task = TaskUnchecked
+	case 'x', 'X':
+		task = TaskChecked
+	}
+
+	s = s[3:]
+	pp.starts[0] = pp.starts[0].advance(line[:len(line)-len(s)])
+	pp.texts[0] = string(s)
+	pp.Start = pp.starts[0]
+	li.Task = task
+}
@@ -0,0 +1,142 @@
+package taomd
+
+import (
+	"strings"
+)
+
+// A TOCEntry is a heading in the table of contents of a document,
+// with the headings under it as its children.
+type TOCEntry struct {
+	Heading *Heading
+
+	Level int
+	Text  string // the text content of the heading
+	ID    string // the id of the heading, empty if it has none
+
+	Children []*TOCEntry
+}
+
+// A TableOfContents is a paragraph of only the TOC marker, as in "[TOC]",
+// which is replaced by the table of contents of the document.
+// See WithTOC.
+type TableOfContents struct {
+	Span
+
+	// The marker as it is written, after leading and trailing spaces are removed.
+	Marker string
+
+	// The headings of levels from MinLevel to MaxLevel, both inclusive.
+	MinLevel int
+	MaxLevel int
+	Entries  []*TOCEntry
+}
+
+func (t *TableOfContents) AddLine(p *Parser, s []rune) bool {
+	return false
+}
+
+// DefaultTOCMarker is the TOC marker if none is given to WithTOC.
+const DefaultTOCMarker = "[TOC]"
+
+// WithTOC replaces paragraphs of only marker, or DefaultTOCMarker if it is empty,
+// by the table of contents of the document. Only headings of levels from
+// minLevel to maxLevel, both inclusive, are listed. 0s mean levels 1 and 6.
+//
+// Headings are given ids by GitHubSlug, unless WithHeadingIDs is also given.
+func WithTOC(marker string, minLevel int, maxLevel int) Option {
+	return func(p *Parser) {
+		if marker == "" {
+			marker = DefaultTOCMarker
+		}
+		if minLevel <= 0 {
+			minLevel = 1
+		}
+		if maxLevel <= 0 {
+			maxLevel = 6
+		}
+		p.tocMarker = marker
+		p.tocMinLevel = minLevel
+		p.tocMaxLevel = maxLevel
+		if p.slugger == nil {
+			p.slugger = GitHubSlug
+		}
+	}
+}
+
+// TOC returns the headings of the document as a tree, in which
+// each heading is a child of the nearest heading of a lower level before it.
+func (doc *Document) TOC() []*TOCEntry {
+	return doc.toc(1, 6)
+}
+
+// toc returns the headings of levels from min to max as a tree.
+func (doc *Document) toc(min int, max int) []*TOCEntry {
+	var roots []*TOCEntry
+	var stack []*TOCEntry
+
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		h, ok := n.(*Heading)
+		if !ok || !entering {
+			return WalkContinue
+		}
+		if h.Level < min || h.Level > max {
+			return WalkSkipChildren
+		}
+
+		entry := &TOCEntry{
+			Heading: h,
+			Level:   h.Level,
+			Text:    h.TextContent(),
+			ID:      h.ID,
+		}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, entry)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+		stack = append(stack, entry)
+		return WalkSkipChildren
+	})
+
+	return roots
+}
+
+// parseTOCs replaces the paragraphs of only marker by tables of contents,
+// before the paragraphs are parsed into inlines.
+func (doc *Document) parseTOCs(marker string, min int, max int) {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		c, ok := n.(blockContainer)
+		if !ok || !entering {
+			return WalkContinue
+		}
+		blocks := c.Blocks()
+		for i, block := range blocks {
+			pp, ok := block.(*Paragraph)
+			if !ok || strings.TrimSpace(strings.Join(pp.texts, "")) != marker {
+				continue
+			}
+			blocks[i] = &TableOfContents{
+				Span:     pp.Span,
+				Marker:   marker,
+				MinLevel: min,
+				MaxLevel: max,
+			}
+		}
+		return WalkContinue
+	})
+}
+
+// makeTOCs makes the entries of the tables of contents,
+// after the headings are given ids.
+func (doc *Document) makeTOCs() {
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		if t, ok := n.(*TableOfContents); ok && entering {
+			t.Entries = doc.toc(t.MinLevel, t.MaxLevel)
+		}
+		return WalkContinue
+	})
+}
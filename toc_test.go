@@ -0,0 +1,52 @@
+package taomd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTOC(t *testing.T) {
+	testExamples(t, []*Example{
+		{
+			Markdown: "[TOC]\n\n# A\n## B\n### C\n## D\n",
+			HTML:     "<ul>\n<li><a href=\"#a\">A</a>\n<ul>\n<li><a href=\"#b\">B</a>\n<ul>\n<li><a href=\"#c\">C</a></li>\n</ul>\n</li>\n<li><a href=\"#d\">D</a></li>\n</ul>\n</li>\n</ul>\n<h1 id=\"a\">A</h1>\n<h2 id=\"b\">B</h2>\n<h3 id=\"c\">C</h3>\n<h2 id=\"d\">D</h2>\n",
+			Section:  "TOC",
+		},
+		{
+			Markdown: "# A\n\n[toc]\n\n## B\n",
+			HTML:     "<h1 id=\"a\">A</h1>\n<p>[toc]</p>\n<h2 id=\"b\">B</h2>\n",
+			Section:  "TOC",
+		},
+		{
+			Markdown: "text [TOC]\n",
+			HTML:     "<p>text [TOC]</p>\n",
+			Section:  "TOC",
+		},
+		{
+			Markdown: "[TOC]\n",
+			HTML:     "",
+			Section:  "TOC",
+		},
+	}, WithTOC("", 0, 0))
+
+	testExamples(t, []*Example{
+		{
+			Markdown: "[[_TOC_]]\n\n# A\n## B\n### C\n#### D\n",
+			HTML:     "<ul>\n<li><a href=\"#b\">B</a>\n<ul>\n<li><a href=\"#c\">C</a></li>\n</ul>\n</li>\n</ul>\n<h1 id=\"a\">A</h1>\n<h2 id=\"b\">B</h2>\n<h3 id=\"c\">C</h3>\n<h4 id=\"d\">D</h4>\n",
+			Section:  "TOC levels",
+		},
+	}, WithTOC("[[_TOC_]]", 2, 3))
+}
+
+func TestDocumentTOC(t *testing.T) {
+	doc := Parse(strings.NewReader("## A\n# B\n## C\n"), WithHeadingIDs(nil))
+	toc := doc.TOC()
+	if len(toc) != 2 || toc[0].Text != "A" || toc[1].Text != "B" ||
+		len(toc[1].Children) != 1 || toc[1].Children[0].ID != "c" {
+		t.Fatalf("toc: %+v", toc)
+	}
+	want := "<ul>\n<li><a href=\"#a\">A</a></li>\n<li><a href=\"#b\">B</a>\n<ul>\n<li><a href=\"#c\">C</a></li>\n</ul>\n</li>\n</ul>\n"
+	if html := RenderTOC(toc); html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+}
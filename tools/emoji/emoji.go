@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+type _Emoji struct {
+	Emoji   string   `json:"emoji"`
+	Aliases []string `json:"aliases"`
+}
+
+func main() {
+	resp, err := http.Get("https://raw.githubusercontent.com/github/gemoji/master/db/emoji.json")
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	var emojis []_Emoji
+	if err := json.NewDecoder(resp.Body).Decode(&emojis); err != nil {
+		panic(err)
+	}
+
+	shortcodes := make(map[string]string)
+	for _, emoji := range emojis {
+		for _, alias := range emoji.Aliases {
+			// the first one wins
+			if _, ok := shortcodes[alias]; !ok {
+				shortcodes[alias] = emoji.Emoji
+			}
+		}
+	}
+
+	sortedKeys := make([]string, 0, len(shortcodes))
+	for k := range shortcodes {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	s := "var emojis = map[string]string{\n"
+	for _, key := range sortedKeys {
+		// escaped, for zero width joiners and variation selectors are invisible
+		s += fmt.Sprintf("\t`%s`: %s,\n", key, strconv.QuoteToASCII(shortcodes[key]))
+	}
+	s += "}"
+
+	fmt.Println(s)
+}
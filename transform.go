@@ -0,0 +1,46 @@
+package taomd
+
+import (
+	"sort"
+)
+
+// A Transformer rewrites a document after it has been parsed,
+// that is, after link reference definitions are resolved and inlines are parsed,
+// and before it is rendered. Whether lists are tight is resolved after
+// the transformers run, so blocks they add to lists are rendered as the
+// lists are.
+//
+// A non-nil error stops the transforming and is returned by ParseReader.
+type Transformer func(doc *Document) error
+
+type prioritizedTransformer struct {
+	transformer Transformer
+	priority    int
+}
+
+// WithTransformer adds a transformer to be run with the given priority.
+//
+// Transformers run in ascending order of priority. Transformers of
+// the same priority run in the order they are added.
+func WithTransformer(t Transformer, priority int) Option {
+	return func(p *Parser) {
+		p.transformers = append(p.transformers, prioritizedTransformer{
+			transformer: t,
+			priority:    priority,
+		})
+	}
+}
+
+// transform runs all transformers on doc.
+func (p *Parser) transform(doc *Document) error {
+	transformers := p.transformers
+	sort.SliceStable(transformers, func(i, j int) bool {
+		return transformers[i].priority < transformers[j].priority
+	})
+	for _, t := range transformers {
+		if err := t.transformer(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
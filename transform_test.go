@@ -0,0 +1,57 @@
+package taomd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransformerOrder(t *testing.T) {
+	var order []string
+	add := func(name string, priority int) Option {
+		return WithTransformer(func(doc *Document) error {
+			order = append(order, name)
+			return nil
+		}, priority)
+	}
+	Parse(strings.NewReader("foo\n"), add("c", 2), add("a", 1), add("d", 2), add("b", 1))
+	if got, want := strings.Join(order, ""), "abcd"; got != want {
+		t.Errorf("want %s, given %s", want, got)
+	}
+}
+
+func TestTransformer(t *testing.T) {
+	// Transformers see the document with inlines parsed.
+	upper := WithTransformer(func(doc *Document) error {
+		Walk(doc, func(n Node, entering bool) WalkStatus {
+			if text, ok := n.(*Text); ok && entering {
+				text.Text = strings.ToUpper(text.Text)
+			}
+			return WalkContinue
+		})
+		return nil
+	}, 0)
+	doc := Parse(strings.NewReader("# foo\n\n*bar* [baz]\n\n[baz]: /u\n"), upper)
+	want := "<h1>FOO</h1>\n<p><em>BAR</em> <a href=\"/u\">BAZ</a></p>\n"
+	if html := Render(doc); html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+}
+
+func TestTransformerTightness(t *testing.T) {
+	// A paragraph added to a tight list is rendered as tight.
+	addItem := WithTransformer(func(doc *Document) error {
+		l := doc.Blocks()[0].(*List)
+		pp := &Paragraph{Inlines: []Inline{&Text{Text: "b"}}}
+		l.Items = append(l.Items, &ListItem{blocks: []Blocker{pp}})
+		return nil
+	}, 0)
+
+	doc, err := ParseReader(strings.NewReader("- a\n"), addItem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"
+	if html := Render(doc); html != want {
+		t.Errorf("want:\n%s\ngiven:\n%s", want, html)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"container/list"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -118,6 +119,8 @@ func NewLineScanner(in io.Reader) *LineScanner {
 		// Request more data.
 		return 0, nil, nil
 	})
+	// Lines are not limited in length; the whole input may be limited by the caller.
+	scn.Buffer(nil, maxLineSize)
 	return &LineScanner{
 		scanner: scn,
 		buffers: list.New(),
@@ -140,10 +143,43 @@ func (ls *LineScanner) Text() []rune {
 	return ls.text
 }
 
+// Err returns the first non-EOF error encountered while scanning.
+func (ls *LineScanner) Err() error {
+	return ls.scanner.Err()
+}
+
 func (ls *LineScanner) PutBack(s []rune) {
 	ls.buffers.PushFront(s)
 }
 
+const maxLineSize = 1<<31 - 1
+
+var errTooLarge = errors.New("input too large")
+
+// limitedReader reads at most n bytes from r,
+// and fails with errTooLarge if r has more.
+type limitedReader struct {
+	r io.Reader
+	n int
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errTooLarge
+	}
+	// read one more byte to tell an exact fit from an overflow.
+	if len(p) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= n
+	if l.n < 0 {
+		n += l.n
+		return n, errTooLarge
+	}
+	return n, err
+}
+
 // Percent-encode a string, avoiding double encoding.
 // Don't touch /a-zA-Z0-9/ + excluded chars + /%[a-fA-F0-9]{2}/ (if not disabled).
 // Bad character not processed.
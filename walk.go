@@ -0,0 +1,41 @@
+package taomd
+
+// WalkStatus tells Walk what to do after visiting a node.
+type WalkStatus int
+
+const (
+	// WalkContinue continues walking.
+	WalkContinue WalkStatus = iota
+
+	// WalkSkipChildren skips the children of the node being entered.
+	// The node is still exited.
+	WalkSkipChildren
+
+	// WalkStop stops walking immediately.
+	WalkStop
+)
+
+// A Walker is called by Walk with entering set to true before the children of n
+// are walked, and with entering set to false after them.
+type Walker func(n Node, entering bool) WalkStatus
+
+// Walk walks the tree rooted at node in depth-first order, blocks and inlines alike.
+func Walk(node Node, walker Walker) {
+	walk(node, walker)
+}
+
+// walk walks node, and returns false if walking has been stopped.
+func walk(node Node, walker Walker) bool {
+	status := walker(node, true)
+	if status == WalkStop {
+		return false
+	}
+	if c, ok := node.(Container); ok && status != WalkSkipChildren {
+		for _, child := range c.Children() {
+			if !walk(child, walker) {
+				return false
+			}
+		}
+	}
+	return walker(node, false) != WalkStop
+}
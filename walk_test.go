@@ -0,0 +1,52 @@
+package taomd
+
+import (
+	"strings"
+	"testing"
+)
+
+// walkKinds walks doc, with status returned for nodes of kind when entering,
+// and lists the kinds entered and exited, as "+Kind" and "-Kind".
+func walkKinds(doc *Document, kind Kind, status WalkStatus) string {
+	var kinds []string
+	Walk(doc, func(n Node, entering bool) WalkStatus {
+		if entering {
+			kinds = append(kinds, "+"+n.Kind().String())
+			if n.Kind() == kind {
+				return status
+			}
+		} else {
+			kinds = append(kinds, "-"+n.Kind().String())
+		}
+		return WalkContinue
+	})
+	return strings.Join(kinds, " ")
+}
+
+func TestWalk(t *testing.T) {
+	doc := Parse(strings.NewReader("> *a*\n\nb\n"))
+	tests := []struct {
+		status WalkStatus
+		want   string
+	}{
+		{
+			WalkContinue,
+			"+Document +BlockQuote +Paragraph +Emphasis +Text -Text -Emphasis -Paragraph -BlockQuote" +
+				" +BlankLine -BlankLine +Paragraph +Text -Text -Paragraph -Document",
+		},
+		{
+			WalkSkipChildren,
+			"+Document +BlockQuote +Paragraph +Emphasis -Emphasis -Paragraph -BlockQuote" +
+				" +BlankLine -BlankLine +Paragraph +Text -Text -Paragraph -Document",
+		},
+		{
+			WalkStop,
+			"+Document +BlockQuote +Paragraph +Emphasis",
+		},
+	}
+	for _, test := range tests {
+		if got := walkKinds(doc, KindEmphasis, test.status); got != test.want {
+			t.Errorf("status %d:\nwant  %s\ngiven %s", test.status, test.want, got)
+		}
+	}
+}